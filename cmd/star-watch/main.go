@@ -7,12 +7,20 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/kevinmichaelchen/star-watch/internal/config"
 	"github.com/kevinmichaelchen/star-watch/internal/embedding"
+	"github.com/kevinmichaelchen/star-watch/internal/export"
+	"github.com/kevinmichaelchen/star-watch/internal/github"
+	"github.com/kevinmichaelchen/star-watch/internal/llm"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	"github.com/kevinmichaelchen/star-watch/internal/organize"
 	"github.com/kevinmichaelchen/star-watch/internal/pipeline"
 	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+	"github.com/kevinmichaelchen/star-watch/internal/trends"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 func main() {
@@ -21,7 +29,7 @@ func main() {
 		Short: "GitHub star list → SurrealDB with AI enrichment",
 	}
 
-	root.AddCommand(schemaCmd(), syncCmd(), searchCmd(), statsCmd())
+	root.AddCommand(schemaCmd(), listsCmd(), syncCmd(), importCmd(), searchCmd(), statsCmd(), exportCmd(), pruneCmd(), organizeCmd(), trendsCmd(), releasesCmd())
 
 	if err := root.Execute(); err != nil {
 		os.Exit(1)
@@ -51,8 +59,63 @@ func schemaCmd() *cobra.Command {
 	}
 }
 
+func listsCmd() *cobra.Command {
+	var (
+		source  string
+		jsonOut bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lists",
+		Short: "Show your GitHub star lists and their IDs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			cfg := config.Load()
+
+			gh, err := pipeline.GitHubClient(cfg, source)
+			if err != nil {
+				return err
+			}
+			lists, err := gh.FetchLists(ctx)
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				if lists == nil {
+					lists = []github.List{}
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(lists)
+			}
+
+			if len(lists) == 0 {
+				fmt.Println("No star lists found")
+				return nil
+			}
+			for _, l := range lists {
+				fmt.Printf("%s  (%d repos)\n", l.Name, l.Items)
+				fmt.Printf("   ID:   %s\n", l.ID)
+				fmt.Printf("   Slug: %s\n", l.Slug)
+				if l.Description != nil && *l.Description != "" {
+					fmt.Printf("   %s\n", *l.Description)
+				}
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&source, "source", "", "GitHub source from GITHUB_SOURCES (default: github.com)")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON array")
+	return cmd
+}
+
 func syncCmd() *cobra.Command {
-	var skipEnrich, force, refresh bool
+	var skipEnrich, skipReleases, force, refresh, resume, dryRun bool
+	var lists, only, skip []string
+	var maxCost float64
+	var maxTokens int
 
 	cmd := &cobra.Command{
 		Use:   "sync",
@@ -60,15 +123,50 @@ func syncCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.Load()
 			return pipeline.Run(context.Background(), cfg, pipeline.Options{
-				SkipEnrich: skipEnrich,
-				Force:      force,
-				Refresh:    refresh,
+				SkipEnrich:   skipEnrich,
+				SkipReleases: skipReleases,
+				Force:        force,
+				Refresh:      refresh,
+				ListIDs:      lists,
+				Only:         only,
+				Skip:         skip,
+				Resume:       resume,
+				DryRun:       dryRun,
+				MaxCost:      maxCost,
+				MaxTokens:    maxTokens,
 			})
 		},
 	}
 	cmd.Flags().BoolVar(&skipEnrich, "skip-enrich", false, "Fetch and store only (no AI calls)")
-	cmd.Flags().BoolVar(&force, "force", false, "Re-enrich all repos")
+	cmd.Flags().BoolVar(&skipReleases, "skip-releases", false, "Don't fetch recent releases")
+	cmd.Flags().BoolVar(&force, "force", false, "Re-enrich and re-embed all repos")
 	cmd.Flags().BoolVar(&refresh, "refresh", false, "Re-fetch from GitHub (ignores cache)")
+	stageNames := strings.Join(pipeline.StageNames(), ", ")
+	cmd.Flags().StringSliceVar(&only, "only", nil, "Run only these stages ("+stageNames+")")
+	cmd.Flags().StringSliceVar(&skip, "skip", nil, "Skip these stages; stages that need them are skipped too")
+	cmd.MarkFlagsMutuallyExclusive("only", "skip")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Continue the last sync if it was interrupted, with its original options")
+	cmd.Flags().StringSliceVar(&lists, "list", nil, "Star list(s) to sync as [source:]ID, name or slug, or \"starred\" for all stars; quote names containing commas (default: STAR_LIST_ID)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show which repos would be enriched and embedded and the estimated cost, without AI calls or database writes")
+	cmd.Flags().Float64Var(&maxCost, "max-cost", 0, "Stop enriching and embedding once this many USD are spent (default: MAX_COST)")
+	cmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Stop enriching and embedding once this many tokens are used (default: MAX_TOKENS)")
+	return cmd
+}
+
+func importCmd() *cobra.Command {
+	var opts pipeline.ImportOptions
+
+	cmd := &cobra.Command{
+		Use:   "import <file-or-url>",
+		Short: "Import repos linked from an awesome-style markdown file or URL",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Load()
+			return pipeline.Import(context.Background(), cfg, args[0], opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Label, "label", "", "Source to add to import_source (default: the file path or URL)")
+	cmd.Flags().BoolVar(&opts.SkipEnrich, "skip-enrich", false, "Fetch and store only (no AI calls)")
 	return cmd
 }
 
@@ -76,10 +174,13 @@ const defaultFields = "full_name,description,ai_summary,ai_categories,stars,url,
 
 func searchCmd() *cobra.Command {
 	var (
-		k         int
-		jsonOut   bool
-		fieldsRaw string
-		sortRaw   string
+		k           int
+		jsonOut     bool
+		fieldsRaw   string
+		sortRaw     string
+		list        string
+		source      string
+		withRemoved bool
 	)
 
 	cmd := &cobra.Command{
@@ -118,6 +219,10 @@ func searchCmd() *cobra.Command {
 				K:      k,
 				Fields: fields,
 				Sort:   sortSpecs,
+				List:   list,
+				Source: source,
+
+				IncludeRemoved: withRemoved,
 			})
 			if err != nil {
 				return err
@@ -168,6 +273,9 @@ func searchCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output as JSON array")
 	cmd.Flags().StringVar(&fieldsRaw, "fields", defaultFields, "Comma-separated field names")
 	cmd.Flags().StringVar(&sortRaw, "sort", "score desc", "Comma-separated field [asc|desc] specs")
+	cmd.Flags().StringVar(&list, "list", "", "Only search repos in this star list (ID or name)")
+	cmd.Flags().StringVar(&source, "source", "", "Only search repos imported from this source (see import --label)")
+	cmd.Flags().BoolVar(&withRemoved, "include-removed", false, "Include repos removed from all star lists")
 	return cmd
 }
 
@@ -289,7 +397,9 @@ func toStringSlice(v any) []string {
 }
 
 func statsCmd() *cobra.Command {
-	return &cobra.Command{
+	var list string
+
+	cmd := &cobra.Command{
 		Use:   "stats",
 		Short: "Show repo counts and category breakdown",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -302,7 +412,7 @@ func statsCmd() *cobra.Command {
 			}
 			defer func() { _ = db.Close(ctx) }()
 
-			stats, err := db.GetStats(ctx)
+			stats, err := db.GetStats(ctx, list)
 			if err != nil {
 				return err
 			}
@@ -310,8 +420,16 @@ func statsCmd() *cobra.Command {
 			fmt.Printf("Repos:    %d\n", stats.Total)
 			fmt.Printf("Enriched: %d\n", stats.Enriched)
 			fmt.Printf("Embedded: %d\n", stats.Embedded)
+			if stats.Removed > 0 {
+				scope := ""
+				if list != "" {
+					// Removed repos belong to no list, so this count is global.
+					scope = " across all lists"
+				}
+				fmt.Printf("Removed:  %d%s (run `star-watch prune` to delete)\n", stats.Removed, scope)
+			}
 
-			cats, err := db.GetCategoryBreakdown(ctx)
+			cats, err := db.GetCategoryBreakdown(ctx, list)
 			if err != nil {
 				return err
 			}
@@ -329,4 +447,285 @@ func statsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&list, "list", "", "Only count repos in this star list (ID or name)")
+	return cmd
+}
+
+func exportCmd() *cobra.Command {
+	var (
+		format string
+		output string
+		order  []string
+		opts   export.Options
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Render the catalog as a categorized markdown document",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != export.FormatAwesomeMarkdown {
+				return fmt.Errorf("unsupported format %q (supported: %s)", format, export.FormatAwesomeMarkdown)
+			}
+			ctx := context.Background()
+			cfg := config.Load()
+
+			db, err := surrealdb.NewClient(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = db.Close(ctx) }()
+
+			repos, err := db.GetAllRepos(ctx)
+			if err != nil {
+				return err
+			}
+			opts.CategoryOrder = order
+
+			if output == "" || output == "-" {
+				return export.Render(os.Stdout, repos, opts)
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			if err := export.Render(f, repos, opts); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %s\n", output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", export.FormatAwesomeMarkdown, "Output format")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to a file instead of stdout")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Document title (default: Awesome Stars)")
+	cmd.Flags().IntVar(&opts.MinStars, "min-stars", 0, "Leave out repos with fewer stars")
+	cmd.Flags().StringSliceVar(&order, "category-order", nil, "Categories to list first, in order (others follow by size)")
+	cmd.Flags().StringVar(&opts.TemplatePath, "template", "", "Custom Go text/template file")
+	return cmd
+}
+
+func pruneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prune",
+		Short: "Permanently delete repos removed from all star lists",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			cfg := config.Load()
+
+			db, err := surrealdb.NewClient(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = db.Close(ctx) }()
+
+			pruned, err := db.PruneRemoved(ctx)
+			if err != nil {
+				return err
+			}
+			for _, name := range pruned {
+				fmt.Printf("  Deleted %s\n", name)
+			}
+			fmt.Printf("Pruned %d repos\n", len(pruned))
+			return nil
+		},
+	}
+}
+
+func organizeCmd() *cobra.Command {
+	var opts organize.Options
+
+	cmd := &cobra.Command{
+		Use:   "organize",
+		Short: "File repos into GitHub star lists named after their AI categories",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			cfg := config.Load()
+
+			db, err := surrealdb.NewClient(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = db.Close(ctx) }()
+			if err := db.InitSchema(ctx); err != nil {
+				return err
+			}
+
+			gh, err := pipeline.GitHubClient(cfg, "")
+			if err != nil {
+				return err
+			}
+			return organize.Run(ctx, gh, db, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show the changes without touching GitHub")
+	cmd.Flags().BoolVar(&opts.Public, "public", false, "Create missing lists as public (default: private)")
+	return cmd
+}
+
+func trendsCmd() *cobra.Command {
+	var (
+		windowRaw string
+		top       int
+		list      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "trends",
+		Short: "Show which repos gained the most stars recently",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			cfg := config.Load()
+
+			window, err := trends.ParseWindow(windowRaw)
+			if err != nil {
+				return err
+			}
+
+			db, err := surrealdb.NewClient(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = db.Close(ctx) }()
+
+			snapshots, err := db.GetSnapshots(ctx, time.Now().Add(-window), list)
+			if err != nil {
+				return err
+			}
+
+			results := trends.Compute(snapshots, top)
+			if len(results) == 0 {
+				fmt.Printf("Not enough history in the last %s (run `sync` periodically to record star counts)\n", windowRaw)
+				return nil
+			}
+
+			fmt.Printf("Top %d repos by stars gained in the last %s:\n\n", len(results), windowRaw)
+			for i, t := range results {
+				fmt.Printf("%2d. %-40s %+6d  (%.1f/day)  ★ %-7d %s\n",
+					i+1, t.FullName, t.Gained, t.PerDay, t.Stars, trends.Sparkline(t.History, 30))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&windowRaw, "window", "w", "30d", "Look-back window (e.g. 7d, 4w, 72h)")
+	cmd.Flags().IntVarP(&top, "n", "n", 10, "Number of repos to show")
+	cmd.Flags().StringVar(&list, "list", "", "Only include repos in this star list (ID or name)")
+	return cmd
+}
+
+func releasesCmd() *cobra.Command {
+	var (
+		sinceRaw  string
+		list      string
+		noSummary bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "releases",
+		Short: "List releases published since the previous sync",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			cfg := config.Load()
+
+			db, err := surrealdb.NewClient(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = db.Close(ctx) }()
+
+			since, err := resolveSince(ctx, db, sinceRaw)
+			if err != nil {
+				return err
+			}
+
+			releases, err := db.GetReleasesSince(ctx, since, list)
+			if err != nil {
+				return err
+			}
+			if len(releases) == 0 {
+				fmt.Printf("No releases since %s\n", since.Format(time.DateOnly))
+				return nil
+			}
+
+			if !noSummary {
+				summarizeReleases(ctx, cfg, db, releases)
+			}
+
+			fmt.Printf("%d releases since %s:\n\n", len(releases), since.Format(time.DateOnly))
+			for _, r := range releases {
+				date := ""
+				if r.PublishedAt != nil {
+					date = r.PublishedAt.Format(time.DateOnly)
+				}
+				pre := ""
+				if r.Prerelease {
+					pre = " (pre-release)"
+				}
+				fmt.Printf("%s  %s  %s%s\n", date, r.FullName, r.Tag, pre)
+				if r.AISummary != nil && *r.AISummary != "" {
+					fmt.Printf("   %s\n", *r.AISummary)
+				}
+				fmt.Printf("   %s\n\n", r.URL)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&sinceRaw, "since", "", "Date (YYYY-MM-DD) or window (e.g. 7d, 2w); default: previous sync")
+	cmd.Flags().StringVar(&list, "list", "", "Only include repos in this star list (ID or name)")
+	cmd.Flags().BoolVar(&noSummary, "no-summary", false, "Skip LLM summaries of release notes")
+	return cmd
+}
+
+// resolveSince parses --since as a date or look-back window. When empty it
+// falls back to the previous sync, or 7 days if there is none.
+func resolveSince(ctx context.Context, db *surrealdb.Client, raw string) (time.Time, error) {
+	if raw == "" {
+		t, ok, err := db.GetPreviousSyncTime(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ok {
+			return t, nil
+		}
+		return time.Now().AddDate(0, 0, -7), nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	window, err := trends.ParseWindow(raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (use YYYY-MM-DD or e.g. 7d)", raw)
+	}
+	return time.Now().Add(-window), nil
+}
+
+// summarizeReleases fills in missing one-line summaries via the LLM and
+// stores them so later runs reuse them.
+func summarizeReleases(ctx context.Context, cfg *config.Config, db *surrealdb.Client, releases []models.Release) {
+	llmClient := llm.NewClient(cfg.LLMBaseURL, cfg.LLMAPIKey, cfg.LLMModel)
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(5)
+	for i := range releases {
+		r := &releases[i]
+		if r.AISummary != nil || r.Notes == nil {
+			continue
+		}
+		g.Go(func() error {
+			summary, err := llmClient.SummarizeRelease(gCtx, *r)
+			if err != nil {
+				fmt.Printf("  WARN: %v\n", err)
+				return nil
+			}
+			r.AISummary = &summary
+			if err := db.UpdateReleaseSummary(gCtx, r.RepoKey, r.Tag, summary); err != nil {
+				fmt.Printf("  WARN: %v\n", err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
 }
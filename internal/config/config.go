@@ -1,21 +1,38 @@
 package config
 
 import (
+	"encoding/csv"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// GitHubSource is a GitHub instance star lists are fetched from: github.com
+// or a GitHub Enterprise Server.
+type GitHubSource struct {
+	GraphQLURL string // empty means https://api.github.com/graphql
+	Token      string
+	CABundle   string // path to a PEM file of extra trusted CAs
+	ProxyURL   string // empty means use HTTPS_PROXY/NO_PROXY
+}
+
 type Config struct {
 	SurrealURL  string
 	SurrealNS   string
 	SurrealDB   string
 	SurrealUser string
 	SurrealPass string
+	// SurrealBatchSize is how many repos are upserted per transaction.
+	SurrealBatchSize int
 
-	GitHubToken string
-	StarListID  string
+	// GitHubSources is keyed by source name; "" is the default source.
+	// Star list IDs select a named source with a "name:" prefix. Entries
+	// are comma-separated; a name containing commas is wrapped in double
+	// quotes, as with --list.
+	GitHubSources map[string]GitHubSource
+	StarListIDs   []string
 
 	LLMBaseURL string
 	LLMAPIKey  string
@@ -24,6 +41,16 @@ type Config struct {
 	EmbeddingBaseURL string
 	EmbeddingAPIKey  string
 	EmbeddingModel   string
+
+	// ModelPrices overrides the built-in price table used for dry run
+	// estimates and MaxCost budgets, as model=input/output USD per million
+	// tokens (see cost.LoadPrices).
+	ModelPrices string
+
+	// MaxCost (USD) and MaxTokens stop a sync or import once its LLM and
+	// embedding usage reaches them; 0 means no limit.
+	MaxCost   float64
+	MaxTokens int
 }
 
 func Load() *Config {
@@ -36,8 +63,10 @@ func Load() *Config {
 		SurrealUser: os.Getenv("SURREAL_USER"),
 		SurrealPass: os.Getenv("SURREAL_PASS"),
 
-		GitHubToken: os.Getenv("GITHUB_TOKEN"),
-		StarListID:  os.Getenv("STAR_LIST_ID"),
+		SurrealBatchSize: envInt("SURREAL_BATCH_SIZE", 100),
+
+		GitHubSources: loadGitHubSources(),
+		StarListIDs:   splitQuotedList(os.Getenv("STAR_LIST_ID")),
 
 		LLMBaseURL: os.Getenv("LLM_BASE_URL"),
 		LLMAPIKey:  os.Getenv("LLM_API_KEY"),
@@ -46,6 +75,10 @@ func Load() *Config {
 		EmbeddingBaseURL: os.Getenv("EMBEDDING_BASE_URL"),
 		EmbeddingAPIKey:  os.Getenv("EMBEDDING_API_KEY"),
 		EmbeddingModel:   os.Getenv("EMBEDDING_MODEL"),
+
+		ModelPrices: os.Getenv("MODEL_PRICES"),
+		MaxCost:     envFloat("MAX_COST", 0),
+		MaxTokens:   envInt("MAX_TOKENS", 0),
 	}
 
 	// The SDK appends /rpc automatically
@@ -76,3 +109,69 @@ func Load() *Config {
 
 	return cfg
 }
+
+// loadGitHubSources reads the default source from GITHUB_* and each source
+// named in GITHUB_SOURCES (e.g. "ghe") from GITHUB_<NAME>_*.
+func loadGitHubSources() map[string]GitHubSource {
+	sources := map[string]GitHubSource{"": loadGitHubSource("GITHUB_")}
+	for _, name := range splitList(os.Getenv("GITHUB_SOURCES")) {
+		sources[name] = loadGitHubSource("GITHUB_" + strings.ToUpper(name) + "_")
+	}
+	return sources
+}
+
+func loadGitHubSource(prefix string) GitHubSource {
+	return GitHubSource{
+		GraphQLURL: os.Getenv(prefix + "GRAPHQL_URL"),
+		Token:      os.Getenv(prefix + "TOKEN"),
+		CABundle:   os.Getenv(prefix + "CA_BUNDLE"),
+		ProxyURL:   os.Getenv(prefix + "PROXY_URL"),
+	}
+}
+
+// envInt reads a positive integer env value, falling back to def when it is
+// unset or invalid.
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// envFloat is envInt for decimal values.
+func envFloat(key string, def float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && f > 0 {
+		return f
+	}
+	return def
+}
+
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// splitQuotedList is splitList with CSV quoting, the same rules cobra's
+// slice flags use: an entry in double quotes may contain commas, e.g.
+// `"Tools, misc",UL_abc`. Unbalanced quotes fall back to splitList.
+func splitQuotedList(raw string) []string {
+	r := csv.NewReader(strings.NewReader(raw))
+	r.TrimLeadingSpace = true
+	fields, err := r.Read()
+	if err != nil {
+		return splitList(raw)
+	}
+	var out []string
+	for _, s := range fields {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
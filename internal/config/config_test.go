@@ -0,0 +1,25 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitQuotedList(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"", nil},
+		{"UL_a", []string{"UL_a"}},
+		{"UL_a, ghe:UL_b,,", []string{"UL_a", "ghe:UL_b"}},
+		{`UL_a, "Tools, misc"`, []string{"UL_a", "Tools, misc"}},
+		{`"Go: web","ghe:Tools, misc"`, []string{"Go: web", "ghe:Tools, misc"}},
+		{`"unbalanced, UL_a`, []string{`"unbalanced`, "UL_a"}},
+	}
+	for _, tt := range tests {
+		if got := splitQuotedList(tt.raw); !slices.Equal(got, tt.want) {
+			t.Errorf("splitQuotedList(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned by Budget.Check once a limit is reached.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget tracks the tokens and dollars a run has spent against optional
+// limits. A nil *Budget records nothing and never runs out. It is safe for
+// concurrent use.
+type Budget struct {
+	prices    Prices
+	maxCost   float64 // USD; 0 means no limit
+	maxTokens int     // 0 means no limit
+
+	mu       sync.Mutex
+	tokens   int
+	usd      float64
+	unpriced map[string]bool
+}
+
+func NewBudget(prices Prices, maxCost float64, maxTokens int) *Budget {
+	return &Budget{prices: prices, maxCost: maxCost, maxTokens: maxTokens, unpriced: map[string]bool{}}
+}
+
+// Record adds the usage of one request. Tokens of models without a price
+// count toward the token limit only.
+func (b *Budget) Record(model string, input, output int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += input + output
+	if usd, ok := b.prices.Cost(model, input, output); ok {
+		b.usd += usd
+	} else {
+		b.unpriced[model] = true
+	}
+}
+
+// Check returns an error wrapping ErrBudgetExceeded if spending has reached
+// a limit.
+func (b *Budget) Check() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.maxTokens > 0 && b.tokens >= b.maxTokens:
+		return fmt.Errorf("%w: used %d of %d tokens", ErrBudgetExceeded, b.tokens, b.maxTokens)
+	case b.maxCost > 0 && b.usd >= b.maxCost:
+		return fmt.Errorf("%w: spent %s of %s", ErrBudgetExceeded, Format(b.usd), Format(b.maxCost))
+	}
+	return nil
+}
+
+// Priced reports whether model has a price, so a cost limit can account
+// for it.
+func (b *Budget) Priced(model string) bool {
+	if b == nil {
+		return true
+	}
+	_, ok := b.prices[model]
+	return ok
+}
+
+// String summarizes what has been spent, e.g. "12345 tokens, $0.0042".
+func (b *Budget) String() string {
+	if b == nil {
+		return "0 tokens"
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := fmt.Sprintf("%d tokens, %s", b.tokens, Format(b.usd))
+	if len(b.unpriced) > 0 {
+		s += " plus unpriced models"
+	}
+	return s
+}
+
+// Used reports whether anything was recorded.
+func (b *Budget) Used() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > 0
+}
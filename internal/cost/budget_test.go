@@ -0,0 +1,114 @@
+package cost
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBudgetCheck(t *testing.T) {
+	prices := Prices{"chat": {Input: 1, Output: 2}}
+	type usage struct {
+		model         string
+		input, output int
+	}
+	tests := []struct {
+		name      string
+		maxCost   float64
+		maxTokens int
+		usage     []usage
+		wantErr   string
+	}{
+		{
+			name:  "no limits",
+			usage: []usage{{"chat", 1_000_000, 1_000_000}},
+		},
+		{
+			name:      "under token limit",
+			maxTokens: 100,
+			usage:     []usage{{"chat", 40, 20}, {"chat", 30, 0}},
+		},
+		{
+			name:      "token limit reached",
+			maxTokens: 100,
+			usage:     []usage{{"chat", 40, 20}, {"chat", 40, 0}},
+			wantErr:   "used 100 of 100 tokens",
+		},
+		{
+			name:    "under cost limit",
+			maxCost: 1,
+			usage:   []usage{{"chat", 500_000, 200_000}},
+		},
+		{
+			name:    "cost limit reached",
+			maxCost: 1,
+			usage:   []usage{{"chat", 500_000, 200_000}, {"chat", 100_000, 0}},
+			wantErr: "spent $1.00 of $1.00",
+		},
+		{
+			name:    "unpriced model only counts tokens",
+			maxCost: 1,
+			usage:   []usage{{"other", 10_000_000, 10_000_000}},
+		},
+		{
+			name:      "unpriced model counts toward token limit",
+			maxTokens: 100,
+			usage:     []usage{{"other", 100, 0}},
+			wantErr:   "used 100 of 100 tokens",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBudget(prices, tt.maxCost, tt.maxTokens)
+			for _, u := range tt.usage {
+				b.Record(u.model, u.input, u.output)
+			}
+			err := b.Check()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrBudgetExceeded) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check() = %v, want ErrBudgetExceeded with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBudgetPriced(t *testing.T) {
+	b := NewBudget(Prices{"chat": {Input: 1}}, 1, 0)
+	if !b.Priced("chat") {
+		t.Error(`Priced("chat") = false, want true`)
+	}
+	if b.Priced("other") {
+		t.Error(`Priced("other") = true, want false`)
+	}
+}
+
+func TestBudgetString(t *testing.T) {
+	b := NewBudget(Prices{"chat": {Input: 1, Output: 2}}, 0, 0)
+	if b.Used() {
+		t.Error("Used() = true before anything was recorded")
+	}
+	b.Record("chat", 1000, 500)
+	if got, want := b.String(), "1500 tokens, $0.0020"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	b.Record("other", 10, 0)
+	if got, want := b.String(), "1510 tokens, $0.0020 plus unpriced models"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	b.Record("chat", 1, 1)
+	if err := b.Check(); err != nil {
+		t.Errorf("Check() = %v, want nil", err)
+	}
+	if !b.Priced("anything") || b.Used() {
+		t.Error("nil budget should price every model and record nothing")
+	}
+}
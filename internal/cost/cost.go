@@ -0,0 +1,86 @@
+// Package cost estimates token counts and prices LLM and embedding usage.
+package cost
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price is what a model costs in USD per million tokens. Embedding models
+// only have an input price.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// Prices maps model names to their price.
+type Prices map[string]Price
+
+// defaultPrices are list prices of common models, including the Fireworks
+// defaults. Anything else needs an entry in MODEL_PRICES.
+var defaultPrices = Prices{
+	"accounts/fireworks/models/glm-5": {Input: 1.00, Output: 3.20},
+	"gpt-4o":                          {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":                     {Input: 0.15, Output: 0.60},
+	"gpt-4.1":                         {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":                    {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":                    {Input: 0.10, Output: 0.40},
+	"text-embedding-3-small":          {Input: 0.02},
+	"text-embedding-3-large":          {Input: 0.13},
+	"text-embedding-ada-002":          {Input: 0.10},
+	"nomic-ai/nomic-embed-text-v1.5":  {Input: 0.008},
+}
+
+// LoadPrices returns the default price table with overrides applied.
+// overrides is a comma-separated list of model=input/output entries in USD
+// per million tokens, e.g. "gpt-4o-mini=0.15/0.60,text-embedding-3-small=0.02".
+func LoadPrices(overrides string) (Prices, error) {
+	prices := make(Prices, len(defaultPrices))
+	for model, p := range defaultPrices {
+		prices[model] = p
+	}
+	for _, entry := range strings.Split(overrides, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Model names may contain slashes, so split on the last "=".
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid price %q (want model=input/output)", entry)
+		}
+		model, raw := strings.TrimSpace(entry[:i]), entry[i+1:]
+		in, out, _ := strings.Cut(raw, "/")
+		var p Price
+		var err error
+		if p.Input, err = strconv.ParseFloat(strings.TrimSpace(in), 64); err != nil {
+			return nil, fmt.Errorf("invalid input price for %s: %w", model, err)
+		}
+		if out != "" {
+			if p.Output, err = strconv.ParseFloat(strings.TrimSpace(out), 64); err != nil {
+				return nil, fmt.Errorf("invalid output price for %s: %w", model, err)
+			}
+		}
+		prices[model] = p
+	}
+	return prices, nil
+}
+
+// Cost prices input and output tokens for model. ok is false when the
+// model has no price.
+func (p Prices) Cost(model string, input, output int) (usd float64, ok bool) {
+	price, ok := p[model]
+	if !ok {
+		return 0, false
+	}
+	return (float64(input)*price.Input + float64(output)*price.Output) / 1e6, true
+}
+
+// Format renders a dollar amount with enough precision for small totals.
+func Format(usd float64) string {
+	if usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
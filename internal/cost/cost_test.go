@@ -0,0 +1,113 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadPrices(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+		model     string
+		want      Price
+		wantErr   string
+	}{
+		{
+			name:  "default",
+			model: "gpt-4o-mini",
+			want:  Price{Input: 0.15, Output: 0.60},
+		},
+		{
+			name:  "default chat model",
+			model: "accounts/fireworks/models/glm-5",
+			want:  Price{Input: 1.00, Output: 3.20},
+		},
+		{
+			name:      "override default",
+			overrides: "gpt-4o-mini=0.10/0.40",
+			model:     "gpt-4o-mini",
+			want:      Price{Input: 0.10, Output: 0.40},
+		},
+		{
+			name:      "model with slashes and spaces",
+			overrides: " accounts/fireworks/models/glm-5 = 1/3 , ",
+			model:     "accounts/fireworks/models/glm-5",
+			want:      Price{Input: 1, Output: 3},
+		},
+		{
+			name:      "input only",
+			overrides: "my-embedder=0.05",
+			model:     "my-embedder",
+			want:      Price{Input: 0.05},
+		},
+		{
+			name:      "missing model",
+			overrides: "=1/2",
+			wantErr:   "want model=input/output",
+		},
+		{
+			name:      "bad input",
+			overrides: "m=abc/1",
+			wantErr:   "invalid input price for m",
+		},
+		{
+			name:      "bad output",
+			overrides: "m=1/abc",
+			wantErr:   "invalid output price for m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, err := LoadPrices(tt.overrides)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadPrices() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPrices() error = %v", err)
+			}
+			if got, ok := prices[tt.model]; !ok || got != tt.want {
+				t.Errorf("prices[%q] = %v, %v, want %v", tt.model, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPricesKeepsDefaults(t *testing.T) {
+	if _, err := LoadPrices("gpt-4o=1/2"); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultPrices["gpt-4o"]; got != (Price{Input: 2.50, Output: 10.00}) {
+		t.Errorf("override changed defaultPrices: %v", got)
+	}
+}
+
+func TestCost(t *testing.T) {
+	prices := Prices{"m": {Input: 2, Output: 8}}
+	if usd, ok := prices.Cost("m", 1_000_000, 500_000); !ok || usd != 6 {
+		t.Errorf("Cost() = %v, %v, want 6, true", usd, ok)
+	}
+	if _, ok := prices.Cost("unknown", 1, 1); ok {
+		t.Error("Cost() of unpriced model reported ok")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		usd  float64
+		want string
+	}{
+		{0, "$0.0000"},
+		{0.00123, "$0.0012"},
+		{0.01, "$0.01"},
+		{12.345, "$12.35"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.usd); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.usd, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cost
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	tiktoken "github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Load BPE ranks from the embedded files instead of downloading them.
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// BPE encodings of OpenAI models.
+const (
+	CL100K = "cl100k_base"
+	O200K  = "o200k_base"
+)
+
+// modelEncodings maps OpenAI model name prefixes to their encoding. The
+// first match wins, so gpt-4o is listed before gpt-4.
+var modelEncodings = []struct{ prefix, encoding string }{
+	{"gpt-4o", O200K},
+	{"chatgpt-4o", O200K},
+	{"gpt-4.1", O200K},
+	{"gpt-4.5", O200K},
+	{"gpt-5", O200K},
+	{"o1", O200K},
+	{"o3", O200K},
+	{"o4", O200K},
+	{"gpt-4", CL100K},
+	{"gpt-3.5", CL100K},
+	{"text-embedding-3", CL100K},
+	{"text-embedding-ada-002", CL100K},
+}
+
+// Encoding returns the BPE encoding model's tokenizer uses. Other
+// providers' tokenizers aren't bundled, so their models are counted with
+// cl100k_base and exact is false; expect those counts to be off by several
+// percent. A provider prefix such as "openai/" is ignored.
+func Encoding(model string) (encoding string, exact bool) {
+	m := strings.ToLower(path.Base(model))
+	for _, e := range modelEncodings {
+		if rest, ok := strings.CutPrefix(m, e.prefix); ok && (rest == "" || rest[0] == '-' || rest[0] == '.') {
+			return e.encoding, true
+		}
+	}
+	return CL100K, false
+}
+
+var (
+	encodersMu sync.Mutex
+	encoders   = map[string]*tiktoken.Tiktoken{}
+)
+
+// encoder returns the tokenizer of an encoding, building it on first use.
+func encoder(encoding string) *tiktoken.Tiktoken {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if enc, ok := encoders[encoding]; ok {
+		return enc
+	}
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		// The ranks are embedded, so this only fails for unknown names.
+		panic(fmt.Sprintf("cost: loading %s: %v", encoding, err))
+	}
+	encoders[encoding] = enc
+	return enc
+}
+
+// Count returns how many tokens model's tokenizer produces for text.
+// Special tokens such as <|endoftext|> are counted as plain text, the way
+// the APIs treat them in user content.
+func Count(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	encoding, _ := Encoding(model)
+	return len(encoder(encoding).EncodeOrdinary(text))
+}
+
+// perMessage is the chat format overhead per message, plus the tokens that
+// prime the reply.
+const (
+	perMessage = 3
+	perReply   = 3
+)
+
+// ChatTokens estimates the prompt tokens of a chat completion with the
+// given message contents.
+func ChatTokens(model string, messages ...string) int {
+	n := perReply
+	for _, m := range messages {
+		n += perMessage + Count(model, m)
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package cost
+
+import "testing"
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		model     string
+		want      string
+		wantExact bool
+	}{
+		{"gpt-4o-mini", O200K, true},
+		{"gpt-4.1-nano", O200K, true},
+		{"gpt-5", O200K, true},
+		{"o3-mini", O200K, true},
+		{"openai/gpt-4o", O200K, true},
+		{"gpt-4", CL100K, true},
+		{"gpt-4-turbo", CL100K, true},
+		{"gpt-3.5-turbo", CL100K, true},
+		{"text-embedding-3-small", CL100K, true},
+		{"text-embedding-ada-002", CL100K, true},
+		{"o1x", CL100K, false},
+		{"accounts/fireworks/models/glm-5", CL100K, false},
+		{"nomic-ai/nomic-embed-text-v1.5", CL100K, false},
+	}
+	for _, tt := range tests {
+		if got, exact := Encoding(tt.model); got != tt.want || exact != tt.wantExact {
+			t.Errorf("Encoding(%q) = %s, %v, want %s, %v", tt.model, got, exact, tt.want, tt.wantExact)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	// Counts from OpenAI's tiktoken for each encoding.
+	tests := []struct {
+		text   string
+		cl100k int
+		o200k  int
+	}{
+		{"", 0, 0},
+		{"hello world", 2, 2},
+		{"tiktoken is great!", 6, 6},
+		{"antidisestablishmentarianism", 6, 6},
+		{"2 + 2 = 4", 7, 7},
+		{"お誕生日おめでとう", 9, 8},
+		{"<|endoftext|>", 7, 7},
+	}
+	for _, tt := range tests {
+		if got := Count("text-embedding-3-small", tt.text); got != tt.cl100k {
+			t.Errorf("cl100k Count(%q) = %d, want %d", tt.text, got, tt.cl100k)
+		}
+		if got := Count("gpt-4o", tt.text); got != tt.o200k {
+			t.Errorf("o200k Count(%q) = %d, want %d", tt.text, got, tt.o200k)
+		}
+	}
+}
+
+func TestChatTokens(t *testing.T) {
+	// Two messages of two tokens each, plus per-message and reply overhead.
+	if got, want := ChatTokens("gpt-4o", "hello world", "hello world"), 3+2*3+2*2; got != want {
+		t.Errorf("ChatTokens() = %d, want %d", got, want)
+	}
+}
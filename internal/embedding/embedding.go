@@ -2,51 +2,274 @@ package embedding
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/kevinmichaelchen/star-watch/internal/cost"
 	openai "github.com/sashabaranov/go-openai"
 )
 
 type Client struct {
 	client *openai.Client
 	model  openai.EmbeddingModel
+	budget *cost.Budget
+
+	// sleep waits out retry backoff; tests replace it to skip real timers.
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
 func NewClient(baseURL, apiKey, model string) *Client {
 	cfg := openai.DefaultConfig(apiKey)
 	cfg.BaseURL = strings.TrimSuffix(baseURL, "/")
+	cfg.HTTPClient = &http.Client{Transport: retryAfterTransport{base: http.DefaultTransport}}
 	return &Client{
 		client: openai.NewClientWithConfig(cfg),
 		model:  openai.EmbeddingModel(model),
+		sleep:  sleep,
 	}
 }
 
+// SetBudget records token usage in b. EmbedStream stops once b is
+// exhausted.
+func (c *Client) SetBudget(b *cost.Budget) { c.budget = b }
+
 const maxBatchSize = 256
 
+const (
+	// maxAttempts is how often a batch is tried before it is split.
+	maxAttempts = 3
+	baseBackoff = time.Second
+	// maxRetryAfter caps how long a Retry-After header can stall a batch.
+	maxRetryAfter = 5 * time.Minute
+)
+
 func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
 
-	vectors := make([][]float32, len(texts))
+	vectors := make([][]float32, 0, len(texts))
 	for start := 0; start < len(texts); start += maxBatchSize {
-		end := start + maxBatchSize
-		if end > len(texts) {
-			end = len(texts)
-		}
-		batch := texts[start:end]
-
-		resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-			Input: batch,
-			Model: c.model,
-		})
+		end := min(start+maxBatchSize, len(texts))
+		batch, err := c.embedWithRetry(ctx, texts[start:end])
 		if err != nil {
 			return nil, fmt.Errorf("creating embeddings (batch %d-%d): %w", start, end, err)
 		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// errBadResponse marks a successful response that didn't hold one vector
+// per input.
+var errBadResponse = errors.New("malformed embeddings response")
+
+// Failure is a text that could not be embedded.
+type Failure struct {
+	Index int // position in the texts passed to EmbedStream
+	Err   error
+}
+
+// EmbedStream embeds texts batch by batch and hands each batch's vectors to
+// store as soon as they arrive, so completed work survives later failures.
+// A failing batch is retried with backoff, then split in half to isolate the
+// inputs that fail; store errors fail the whole batch. It returns the texts
+// that could not be embedded or stored. Only context cancellation and an
+// exhausted budget abort the stream, returning the failures so far and the
+// reason; texts after the current batch are then left unembedded.
+func (c *Client) EmbedStream(ctx context.Context, texts []string, store func(indices []int, vectors [][]float32) error) ([]Failure, error) {
+	var failed []Failure
+	for start := 0; start < len(texts); start += maxBatchSize {
+		if err := c.budget.Check(); err != nil {
+			return failed, err
+		}
+		end := min(start+maxBatchSize, len(texts))
+		indices := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			indices = append(indices, i)
+		}
+		failed = append(failed, c.embedIsolating(ctx, texts, indices, store)...)
+		if ctx.Err() != nil {
+			return failed, ctx.Err()
+		}
+	}
+	return failed, nil
+}
+
+// embedIsolating embeds texts[indices], splitting the batch in half once
+// retries run out so that one bad input (or a request the endpoint keeps
+// choking on) only costs the texts actually at fault. Cancellation stops the
+// splitting.
+func (c *Client) embedIsolating(ctx context.Context, texts []string, indices []int, store func([]int, [][]float32) error) []Failure {
+	batch := make([]string, len(indices))
+	for i, idx := range indices {
+		batch[i] = texts[idx]
+	}
+
+	vectors, err := c.embedWithRetry(ctx, batch)
+	if err == nil {
+		if err := store(indices, vectors); err != nil {
+			return failures(indices, fmt.Errorf("storing embeddings: %w", err))
+		}
+		return nil
+	}
+	if ctx.Err() != nil || len(indices) == 1 {
+		return failures(indices, err)
+	}
+
+	mid := len(indices) / 2
+	return append(
+		c.embedIsolating(ctx, texts, indices[:mid], store),
+		c.embedIsolating(ctx, texts, indices[mid:], store)...,
+	)
+}
+
+func failures(indices []int, err error) []Failure {
+	out := make([]Failure, len(indices))
+	for i, idx := range indices {
+		out[i] = Failure{Index: idx, Err: err}
+	}
+	return out
+}
+
+// embedWithRetry makes one embeddings request, retrying transient failures
+// (rate limits, server and network errors) with exponential backoff and
+// jitter. A Retry-After header on the failed response takes precedence over
+// the backoff.
+func (c *Client) embedWithRetry(ctx context.Context, batch []string) ([][]float32, error) {
+	var err error
+	var wait time.Duration
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		hint := &retryHint{}
+		var vectors [][]float32
+		if vectors, err = c.embedOnce(context.WithValue(ctx, retryHintKey{}, hint), batch); err == nil {
+			return vectors, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !transient(err) {
+			return nil, err
+		}
+		wait = backoff(attempt)
+		if hint.after > 0 {
+			wait = min(hint.after, maxRetryAfter)
+		}
+	}
+	return nil, err
+}
+
+// backoff returns the wait before retry attempt+1: baseBackoff doubled per
+// attempt, plus up to 50% jitter.
+func backoff(attempt int) time.Duration {
+	wait := baseBackoff << attempt
+	return wait + time.Duration(rand.Int64N(int64(wait)/2))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// retryHintKey carries a *retryHint through the request context so
+// retryAfterTransport can report the Retry-After header, which go-openai's
+// errors don't expose.
+type retryHintKey struct{}
+
+type retryHint struct {
+	after time.Duration
+}
+
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryHintKey{}).(*retryHint); ok {
+		hint.after = retryAfter(resp.Header)
+	}
+	return resp, nil
+}
 
-		for _, emb := range resp.Data {
-			vectors[start+emb.Index] = emb.Embedding
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// httpStatus returns the status code of an API error, or 0 if the request
+// never got a response.
+func httpStatus(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	return 0
+}
+
+func transient(err error) bool {
+	if errors.Is(err, errBadResponse) {
+		return false
+	}
+	status := httpStatus(err)
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+func (c *Client) embedOnce(ctx context.Context, batch []string) ([][]float32, error) {
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: batch,
+		Model: c.model,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokens := resp.Usage.PromptTokens
+	if tokens == 0 {
+		// Not every provider reports usage.
+		for _, text := range batch {
+			tokens += cost.Count(string(c.model), text)
+		}
+	}
+	c.budget.Record(string(c.model), tokens, 0)
+
+	vectors := make([][]float32, len(batch))
+	for _, emb := range resp.Data {
+		if emb.Index < 0 || emb.Index >= len(batch) {
+			return nil, fmt.Errorf("%w: index %d out of range", errBadResponse, emb.Index)
+		}
+		vectors[emb.Index] = emb.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("%w: no embedding for input %d", errBadResponse, i)
 		}
 	}
 	return vectors, nil
@@ -0,0 +1,301 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// embedServer serves /embeddings, embedding "text N" as the vector [N].
+// fail decides, per request, whether to answer with an error status instead
+// (0 means succeed); it also sees how many times the same batch was sent.
+// Calls to fail are serialized.
+func embedServer(t *testing.T, fail func(inputs []string, attempt int) (status int, header http.Header)) (*Client, *[]time.Duration) {
+	t.Helper()
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		key := strings.Join(req.Input, "\x00")
+		mu.Lock()
+		attempt := attempts[key]
+		attempts[key]++
+		status, header := fail(req.Input, attempt)
+		mu.Unlock()
+
+		if status != 0 {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{"message": http.StatusText(status), "type": "test_error"},
+			})
+			return
+		}
+
+		data := make([]map[string]any, len(req.Input))
+		for i, text := range req.Input {
+			n, _ := strconv.Atoi(strings.TrimPrefix(text, "text "))
+			data[i] = map[string]any{"object": "embedding", "index": i, "embedding": []float32{float32(n)}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"object": "list",
+			"data":   data,
+			"usage":  map[string]any{"prompt_tokens": len(req.Input), "total_tokens": len(req.Input)},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient(srv.URL, "key", "test-model")
+	var slept []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+	return c, &slept
+}
+
+func texts(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("text %d", i)
+	}
+	return out
+}
+
+// collect is a store callback recording which indices were stored, checking
+// that each vector belongs to its text.
+func collect(t *testing.T, stored map[int]int) func([]int, [][]float32) error {
+	return func(indices []int, vectors [][]float32) error {
+		for i, idx := range indices {
+			if got := int(vectors[i][0]); got != idx {
+				t.Errorf("index %d stored vector of text %d", idx, got)
+			}
+			stored[idx]++
+		}
+		return nil
+	}
+}
+
+func failedIndices(failed []Failure) []int {
+	out := make([]int, len(failed))
+	for i, f := range failed {
+		out[i] = f.Index
+	}
+	return out
+}
+
+func checkStored(t *testing.T, stored map[int]int, n int, failed []int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		want := 1
+		if slices.Contains(failed, i) {
+			want = 0
+		}
+		if stored[i] != want {
+			t.Errorf("text %d stored %d times, want %d", i, stored[i], want)
+		}
+	}
+}
+
+func TestEmbedStreamIsolatesPoisonedInput(t *testing.T) {
+	c, _ := embedServer(t, func(inputs []string, _ int) (int, http.Header) {
+		if slices.Contains(inputs, "text 137") {
+			return http.StatusBadRequest, nil
+		}
+		return 0, nil
+	})
+
+	stored := map[int]int{}
+	failed, err := c.EmbedStream(context.Background(), texts(256), collect(t, stored))
+	if err != nil {
+		t.Fatalf("EmbedStream: %v", err)
+	}
+	if got := failedIndices(failed); !slices.Equal(got, []int{137}) {
+		t.Fatalf("failed = %v, want [137]", got)
+	}
+	if httpStatus(failed[0].Err) != http.StatusBadRequest {
+		t.Errorf("failure error = %v, want a 400", failed[0].Err)
+	}
+	checkStored(t, stored, 256, []int{137})
+}
+
+func TestEmbedStreamRetriesServerError(t *testing.T) {
+	c, slept := embedServer(t, func(inputs []string, attempt int) (int, http.Header) {
+		if inputs[0] == "text 256" && attempt == 0 {
+			return http.StatusBadGateway, nil
+		}
+		return 0, nil
+	})
+
+	stored := map[int]int{}
+	failed, err := c.EmbedStream(context.Background(), texts(300), collect(t, stored))
+	if err != nil {
+		t.Fatalf("EmbedStream: %v", err)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("failed = %v, want none", failedIndices(failed))
+	}
+	checkStored(t, stored, 300, nil)
+	if len(*slept) != 1 || (*slept)[0] < baseBackoff || (*slept)[0] >= baseBackoff*3/2 {
+		t.Errorf("slept %v, want one backoff in [%v, %v)", *slept, baseBackoff, baseBackoff*3/2)
+	}
+}
+
+func TestEmbedStreamSplitsPersistentServerError(t *testing.T) {
+	c, slept := embedServer(t, func(inputs []string, _ int) (int, http.Header) {
+		if slices.Contains(inputs, "text 5") {
+			return http.StatusInternalServerError, nil
+		}
+		return 0, nil
+	})
+
+	stored := map[int]int{}
+	failed, err := c.EmbedStream(context.Background(), texts(16), collect(t, stored))
+	if err != nil {
+		t.Fatalf("EmbedStream: %v", err)
+	}
+	if got := failedIndices(failed); !slices.Equal(got, []int{5}) {
+		t.Fatalf("failed = %v, want [5]", got)
+	}
+	checkStored(t, stored, 16, []int{5})
+	// Every batch holding text 5 (16, 8, 4, 2, 1 texts) is retried in full.
+	if want := 5 * (maxAttempts - 1); len(*slept) != want {
+		t.Errorf("slept %d times, want %d", len(*slept), want)
+	}
+}
+
+func TestEmbedStreamHonorsRetryAfter(t *testing.T) {
+	c, slept := embedServer(t, func(_ []string, attempt int) (int, http.Header) {
+		if attempt == 0 {
+			return http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}}
+		}
+		return 0, nil
+	})
+
+	stored := map[int]int{}
+	failed, err := c.EmbedStream(context.Background(), texts(3), collect(t, stored))
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("EmbedStream = %v, %v; want no failures", failedIndices(failed), err)
+	}
+	if !slices.Equal(*slept, []time.Duration{7 * time.Second}) {
+		t.Errorf("slept %v, want [7s]", *slept)
+	}
+}
+
+func TestEmbedStreamStoreError(t *testing.T) {
+	var requests int
+	c, _ := embedServer(t, func([]string, int) (int, http.Header) {
+		requests++
+		return 0, nil
+	})
+
+	errDisk := errors.New("disk full")
+	stored := map[int]int{}
+	store := collect(t, stored)
+	failed, err := c.EmbedStream(context.Background(), texts(300), func(indices []int, vectors [][]float32) error {
+		if indices[0] == 0 {
+			return errDisk
+		}
+		return store(indices, vectors)
+	})
+	if err != nil {
+		t.Fatalf("EmbedStream: %v", err)
+	}
+	if len(failed) != maxBatchSize {
+		t.Fatalf("%d failures, want the whole first batch (%d)", len(failed), maxBatchSize)
+	}
+	for i, f := range failed {
+		if f.Index != i || !errors.Is(f.Err, errDisk) {
+			t.Fatalf("failure %d = {%d, %v}, want {%d, %v}", i, f.Index, f.Err, i, errDisk)
+		}
+	}
+	for i := maxBatchSize; i < 300; i++ {
+		if stored[i] != 1 {
+			t.Errorf("text %d stored %d times, want 1", i, stored[i])
+		}
+	}
+	// A store error is not the endpoint's fault, so nothing is re-sent.
+	if requests != 2 {
+		t.Errorf("%d requests, want 2", requests)
+	}
+}
+
+func TestEmbedStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _ := embedServer(t, func([]string, int) (int, http.Header) {
+		cancel()
+		return http.StatusServiceUnavailable, nil
+	})
+
+	failed, err := c.EmbedStream(ctx, texts(300), func([]int, [][]float32) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(failed) != maxBatchSize {
+		t.Errorf("%d failures, want the first batch only (%d)", len(failed), maxBatchSize)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", errors.New("connection reset"), true},
+		{"rate limit", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &openai.APIError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{"unparsed server error", &openai.RequestError{HTTPStatusCode: http.StatusServiceUnavailable}, true},
+		{"bad request", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{"unauthorized", &openai.APIError{HTTPStatusCode: http.StatusUnauthorized}, false},
+		{"malformed response", fmt.Errorf("%w: no embedding for input 3", errBadResponse), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transient(tt.err); got != tt.want {
+				t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.header != "" {
+			h.Set("Retry-After", tt.header)
+		}
+		if got := retryAfter(h); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
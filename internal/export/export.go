@@ -0,0 +1,246 @@
+// Package export renders the repo catalog as documents for publishing.
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+// FormatAwesomeMarkdown is an awesome-list style README grouped by category.
+const FormatAwesomeMarkdown = "awesome-md"
+
+// uncategorized collects repos without AI categories; it always sorts last.
+const uncategorized = "Uncategorized"
+
+type Options struct {
+	Title         string
+	MinStars      int
+	CategoryOrder []string // categories to put first, in this order
+	TemplatePath  string   // custom text/template file; empty uses the default
+}
+
+// Document is the data passed to the template.
+type Document struct {
+	Title      string
+	Generated  time.Time
+	Total      int // distinct repos
+	Categories []Category
+}
+
+type Category struct {
+	Name   string
+	Anchor string // GitHub heading anchor, for the table of contents
+	Repos  []models.Repo
+}
+
+// contentsHeading is the heading of the default template's table of
+// contents, which comes before the category headings.
+const contentsHeading = "Contents"
+
+const defaultTemplate = `# {{.Title}}
+
+> {{.Total}} repos, generated by star-watch on {{.Generated.Format "2006-01-02"}}.
+
+## Contents
+{{range .Categories}}
+- [{{.Name}}](#{{.Anchor}}) ({{len .Repos}})
+{{- end}}
+{{range .Categories}}
+## {{.Name}}
+{{range .Repos}}
+- [{{.FullName}}]({{.URL}}) ★ {{stars .Stars}}{{with deref .Language}} · {{.}}{{end}}{{with summary .}} — {{.}}{{end}}
+{{- end}}
+{{end -}}
+`
+
+var funcs = template.FuncMap{
+	"deref": func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	},
+	"stars":   formatStars,
+	"summary": summary,
+	"anchor":  Anchor,
+}
+
+// Render writes repos as an awesome-list markdown document. Repos below
+// MinStars are left out; a repo with several categories is listed under
+// each of them.
+func Render(w io.Writer, repos []models.Repo, opts Options) error {
+	tmpl := template.New("export").Funcs(funcs)
+	var err error
+	if opts.TemplatePath != "" {
+		text, readErr := os.ReadFile(opts.TemplatePath)
+		if readErr != nil {
+			return fmt.Errorf("reading template: %w", readErr)
+		}
+		tmpl, err = tmpl.Parse(string(text))
+	} else {
+		tmpl, err = tmpl.Parse(defaultTemplate)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = "Awesome Stars"
+	}
+	doc := Build(repos, opts.MinStars, opts.CategoryOrder)
+	doc.Title = title
+	if opts.TemplatePath == "" {
+		// The title and contents headings come first and claim their
+		// anchors before any category of the same name.
+		setAnchors(doc.Categories, title, contentsHeading)
+	}
+	if err := tmpl.Execute(w, doc); err != nil {
+		return fmt.Errorf("rendering template: %w", err)
+	}
+	return nil
+}
+
+// Build groups repos by category. Categories named in order come first, in
+// that order; the rest follow by size, then name. Repos within a category
+// are sorted by stars. Anchors are unique among the categories.
+func Build(repos []models.Repo, minStars int, order []string) Document {
+	doc := Document{Generated: time.Now().UTC()}
+	byCat := map[string][]models.Repo{}
+	for _, r := range repos {
+		if r.Stars < minStars {
+			continue
+		}
+		doc.Total++
+		cats := r.AICategories
+		if len(cats) == 0 {
+			cats = []string{uncategorized}
+		}
+		for _, cat := range cats {
+			byCat[cat] = append(byCat[cat], r)
+		}
+	}
+
+	rank := make(map[string]int, len(order))
+	for i, cat := range order {
+		rank[strings.ToLower(cat)] = i + 1
+	}
+	names := make([]string, 0, len(byCat))
+	for cat := range byCat {
+		names = append(names, cat)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		ra, rb := rank[strings.ToLower(a)], rank[strings.ToLower(b)]
+		switch {
+		case ra != rb && ra > 0 && rb > 0:
+			return ra < rb
+		case ra != rb:
+			return ra > 0 // ranked categories before unranked ones
+		case (a == uncategorized) != (b == uncategorized):
+			return b == uncategorized
+		case len(byCat[a]) != len(byCat[b]):
+			return len(byCat[a]) > len(byCat[b])
+		}
+		return a < b
+	})
+
+	for _, name := range names {
+		catRepos := byCat[name]
+		sort.SliceStable(catRepos, func(i, j int) bool {
+			if catRepos[i].Stars != catRepos[j].Stars {
+				return catRepos[i].Stars > catRepos[j].Stars
+			}
+			return catRepos[i].FullName < catRepos[j].FullName
+		})
+		doc.Categories = append(doc.Categories, Category{Name: name, Repos: catRepos})
+	}
+	setAnchors(doc.Categories)
+	return doc
+}
+
+// setAnchors assigns each category the anchor GitHub gives its heading,
+// after the headings in preceding have claimed theirs.
+func setAnchors(cats []Category, preceding ...string) {
+	var s slugger
+	for _, h := range preceding {
+		s.slug(h)
+	}
+	for i := range cats {
+		cats[i].Anchor = s.slug(cats[i].Name)
+	}
+}
+
+// Anchor returns the fragment GitHub generates for a markdown heading:
+// lowercased, spaces turned into hyphens, and everything but letters,
+// numbers, hyphens and underscores dropped, including emoji and non-ASCII
+// punctuation. It does not know about repeated headings; see slugger.
+func Anchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// slugger hands out anchors for the headings of one document the way
+// GitHub does: a repeated anchor gets a -1, -2, ... suffix, skipping any
+// that an earlier heading already produced.
+type slugger struct {
+	seen map[string]int
+}
+
+func (s *slugger) slug(heading string) string {
+	if s.seen == nil {
+		s.seen = map[string]int{}
+	}
+	base := Anchor(heading)
+	slug := base
+	for {
+		if _, taken := s.seen[slug]; !taken {
+			break
+		}
+		s.seen[base]++
+		slug = fmt.Sprintf("%s-%d", base, s.seen[base])
+	}
+	s.seen[slug] = 0
+	return slug
+}
+
+// formatStars abbreviates large counts: 950, 1.2k, 34k.
+func formatStars(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprint(n)
+	case n < 10000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000), ".0") + "k"
+	default:
+		return fmt.Sprintf("%dk", n/1000)
+	}
+}
+
+// summary is the one-line blurb for a repo: the AI summary, falling back to
+// the GitHub description.
+func summary(r models.Repo) string {
+	s := ""
+	switch {
+	case r.AISummary != nil && *r.AISummary != "":
+		s = *r.AISummary
+	case r.Description != nil:
+		s = *r.Description
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+func TestAnchor(t *testing.T) {
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{"Developer Tools", "developer-tools"},
+		{"AI & ML", "ai--ml"},
+		{"C++", "c"},
+		{"Version 2.0", "version-20"},
+		{"snake_case-name", "snake_case-name"},
+		{"🚀 Rocket Science", "-rocket-science"},
+		{"Emoji ✨ in the middle", "emoji--in-the-middle"},
+		{"Web—Frameworks", "webframeworks"},
+		{"“Quoted” Names", "quoted-names"},
+		{"Données & Café", "données--café"},
+		{"日本語 ツール", "日本語-ツール"},
+	}
+	for _, tt := range tests {
+		if got := Anchor(tt.heading); got != tt.want {
+			t.Errorf("Anchor(%q) = %q, want %q", tt.heading, got, tt.want)
+		}
+	}
+}
+
+func TestSlugger(t *testing.T) {
+	tests := []struct {
+		name     string
+		headings []string
+		want     []string
+	}{
+		{"distinct", []string{"Tools", "Libraries"}, []string{"tools", "libraries"}},
+		{"repeated", []string{"Tools", "Tools", "Tools"}, []string{"tools", "tools-1", "tools-2"}},
+		{"same anchor", []string{"C", "C++", "C#"}, []string{"c", "c-1", "c-2"}},
+		{"suffix already taken", []string{"Foo 1", "Foo", "Foo"}, []string{"foo-1", "foo", "foo-2"}},
+		{"suffixed heading repeated", []string{"Tools", "Tools", "Tools-1", "Tools"}, []string{"tools", "tools-1", "tools-1-1", "tools-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s slugger
+			var got []string
+			for _, h := range tt.headings {
+				got = append(got, s.slug(h))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("slugs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func repo(name string, stars int, cats ...string) models.Repo {
+	return models.Repo{
+		FullName:     name,
+		URL:          "https://github.com/" + name,
+		Stars:        stars,
+		AICategories: cats,
+	}
+}
+
+// TestRenderGolden renders a catalog with --category-order and --min-stars
+// and compares it to testdata/awesome.md. Run with STAR_WATCH_UPDATE_GOLDEN=1
+// to rewrite the file.
+func TestRenderGolden(t *testing.T) {
+	repos := []models.Repo{
+		repo("a/cli", 1200, "CLI Tools"),
+		repo("b/cli", 40, "CLI Tools"), // below --min-stars
+		repo("c/db", 300, "Databases"),
+		repo("d/db", 9000, "Databases"),
+		repo("e/db", 9000, "Databases", "Rust"),
+		repo("f/web", 150, "Web"),
+		repo("g/web", 52000, "Web"),
+		repo("h/misc", 75),
+		repo("i/rust", 500, "Rust"),
+		repo("j/toc", 100, "Contents"),
+		repo("k/cpp", 100, "C++"),
+		repo("l/c", 100, "C"),
+		repo("m/ai", 100, "🤖 AI"),
+	}
+	desc := "A fast, friendly   tool."
+	repos[0].Description = &desc
+
+	var buf bytes.Buffer
+	err := Render(&buf, repos, Options{
+		Title:         "My Stars",
+		MinStars:      50,
+		CategoryOrder: []string{"web", "Missing", "CLI Tools"},
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	got := strings.Replace(buf.String(), time.Now().UTC().Format("2006-01-02"), "DATE", 1)
+
+	path := filepath.Join("testdata", "awesome.md")
+	if os.Getenv("STAR_WATCH_UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("rendered document differs from %s:\n%s", path, got)
+	}
+}
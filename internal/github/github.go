@@ -3,30 +3,128 @@ package github
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/kevinmichaelchen/star-watch/internal/models"
 )
 
-const graphqlEndpoint = "https://api.github.com/graphql"
+// DefaultEndpoint is the GraphQL endpoint of github.com. GitHub Enterprise
+// Server uses https://<host>/api/graphql.
+const DefaultEndpoint = "https://api.github.com/graphql"
 
 // Client is a thin wrapper around the GitHub GraphQL API.
 type Client struct {
+	endpoint   string
 	token      string
 	httpClient *http.Client
+	maxRetries int
+	limiter    *rateLimiter
+	pageSize   *pageSizer
+
+	// sleep waits out backoff and rate limits; tests replace it to skip
+	// real timers.
+	sleep func(ctx context.Context, d time.Duration) error
 }
 
-func NewClient(token string) *Client {
-	return &Client{token: token, httpClient: http.DefaultClient}
+// NewClient creates a client for the given GraphQL endpoint (DefaultEndpoint
+// if empty). A nil httpClient means http.DefaultClient; see NewHTTPClient
+// for proxy and CA bundle support.
+func NewClient(endpoint, token string, httpClient *http.Client) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		endpoint:   endpoint,
+		token:      token,
+		httpClient: httpClient,
+		maxRetries: defaultMaxRetries,
+		limiter:    newRateLimiter(),
+		pageSize:   newPageSizer(),
+		sleep:      sleep,
+	}
+}
+
+// NewHTTPClient returns an HTTP client that trusts the CAs in caBundle (a PEM
+// file) in addition to the system pool and routes requests through proxyURL.
+// Empty arguments keep the defaults (system CAs, HTTPS_PROXY/NO_PROXY).
+func NewHTTPClient(caBundle, proxyURL string) (*http.Client, error) {
+	if caBundle == "" && proxyURL == "" {
+		return http.DefaultClient, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caBundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	return &http.Client{Transport: transport}, nil
 }
 
+// repoFields is the set of Repository fields shared by every query that
+// returns repos. Keep it in sync with repoNode.
+var repoFields = `
+fragment RepoFields on Repository {
+  id
+  databaseId
+  owner { login }
+  name
+  description
+  url
+  homepageUrl
+  stargazerCount
+  forkCount
+  isArchived
+  isFork
+  createdAt
+  pushedAt
+  licenseInfo { spdxId name }
+  issues(states: OPEN) { totalCount }
+  latestRelease { tagName publishedAt }
+  primaryLanguage { name }
+  repositoryTopics(first: 20) {
+    nodes { topic { name } }
+  }
+` + readmeFields + `}
+` + readmeEntriesFragment
+
 // pageQuery supports both forward (first/after) and backward (last/before)
 // Relay pagination via nullable variables.
-const pageQuery = `
+var pageQuery = `
 query($listId: ID!, $first: Int, $after: String, $last: Int, $before: String) {
+  rateLimit { remaining resetAt }
   node(id: $listId) {
     ... on UserList {
       items(first: $first, after: $after, last: $last, before: $before) {
@@ -38,33 +136,41 @@ query($listId: ID!, $first: Int, $after: String, $last: Int, $before: String) {
           startCursor
         }
         nodes {
-          ... on Repository {
-            owner { login }
-            name
-            description
-            url
-            homepageUrl
-            stargazerCount
-            primaryLanguage { name }
-            repositoryTopics(first: 20) {
-              nodes { topic { name } }
-            }
-            object(expression: "HEAD:README.md") {
-              ... on Blob { text }
-            }
-          }
+          ... on Repository { ...RepoFields }
         }
       }
     }
   }
 }
-`
+` + repoFields
+
+// starredQuery pages through the viewer's stars, newest first. Unlike list
+// items, each edge carries a starredAt timestamp.
+var starredQuery = `
+query($first: Int!, $after: String) {
+  rateLimit { remaining resetAt }
+  viewer {
+    starredRepositories(first: $first, after: $after, orderBy: {field: STARRED_AT, direction: DESC}) {
+      totalCount
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+      edges {
+        starredAt
+        node { ...RepoFields }
+      }
+    }
+  }
+}
+` + repoFields
 
 // Page holds one page of results from the star list connection.
 type Page struct {
 	TotalCount int
 	PageInfo   PageInfo
 	Repos      []models.Repo
+	PageSize   int // items requested; may be below 100 after adaptive shrinking
 }
 
 type PageInfo struct {
@@ -75,29 +181,206 @@ type PageInfo struct {
 }
 
 // FetchPageForward returns one page of results using forward pagination
-// (oldest first). Pass nil for after to start from the beginning.
+// (oldest first). Pass nil for after to start from the beginning. The page
+// size adapts to timeouts and resource limit errors (see pagesize.go).
 func (c *Client) FetchPageForward(ctx context.Context, listID string, after *string) (*Page, error) {
-	vars := map[string]any{
-		"listId": listID,
-		"first":  100,
-	}
+	vars := map[string]any{"listId": listID}
 	if after != nil {
 		vars["after"] = *after
 	}
-	return c.fetchPage(ctx, vars)
+	return c.fetchAdaptive(ctx, vars, "first", func(v map[string]any) (*Page, error) {
+		return c.fetchPage(ctx, v, true)
+	})
 }
 
 // FetchPageBackward returns one page of results using backward pagination
 // (newest first). Pass nil for before to start from the end.
 func (c *Client) FetchPageBackward(ctx context.Context, listID string, before *string) (*Page, error) {
-	vars := map[string]any{
-		"listId": listID,
-		"last":   100,
-	}
+	vars := map[string]any{"listId": listID}
 	if before != nil {
 		vars["before"] = *before
 	}
-	return c.fetchPage(ctx, vars)
+	return c.fetchAdaptive(ctx, vars, "last", func(v map[string]any) (*Page, error) {
+		return c.fetchPage(ctx, v, true)
+	})
+}
+
+// FetchHead returns the first n items of a list (oldest first) with a fixed
+// page size. It is used to spot-check the list order against the cache.
+func (c *Client) FetchHead(ctx context.Context, listID string, n int) (*Page, error) {
+	page, err := c.fetchPage(ctx, map[string]any{"listId": listID, "first": n}, false)
+	if err != nil {
+		return nil, err
+	}
+	page.PageSize = n
+	return page, nil
+}
+
+// List identifies a GitHub star list (UserList).
+type List struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug,omitempty"`
+	Description *string `json:"description"`
+	Items       int     `json:"items"`
+}
+
+const listQuery = `
+query($listId: ID!) {
+  node(id: $listId) {
+    ... on UserList { id name }
+  }
+}
+`
+
+// FetchList returns the metadata (currently just the name) of a star list.
+func (c *Client) FetchList(ctx context.Context, listID string) (*List, error) {
+	body, err := c.doGraphQL(ctx, listQuery, map[string]any{"listId": listID})
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Node *struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if data.Node == nil || data.Node.ID == "" {
+		return nil, fmt.Errorf("star list %s not found", listID)
+	}
+	return &List{ID: data.Node.ID, Name: data.Node.Name}, nil
+}
+
+const listsQuery = `
+query($after: String) {
+  rateLimit { remaining resetAt }
+  viewer {
+    lists(first: 100, after: $after) {
+      nodes { id name slug description items { totalCount } }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}
+`
+
+// FetchLists returns all of the viewer's star lists.
+func (c *Client) FetchLists(ctx context.Context) ([]List, error) {
+	var lists []List
+	var after *string
+	for {
+		vars := map[string]any{}
+		if after != nil {
+			vars["after"] = *after
+		}
+		body, err := c.doGraphQL(ctx, listsQuery, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		var data struct {
+			Viewer struct {
+				Lists struct {
+					Nodes []struct {
+						ID          string  `json:"id"`
+						Name        string  `json:"name"`
+						Slug        string  `json:"slug"`
+						Description *string `json:"description"`
+						Items       struct {
+							TotalCount int `json:"totalCount"`
+						} `json:"items"`
+					} `json:"nodes"`
+					PageInfo PageInfo `json:"pageInfo"`
+				} `json:"lists"`
+			} `json:"viewer"`
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+
+		for _, n := range data.Viewer.Lists.Nodes {
+			lists = append(lists, List{
+				ID:          n.ID,
+				Name:        n.Name,
+				Slug:        n.Slug,
+				Description: n.Description,
+				Items:       n.Items.TotalCount,
+			})
+		}
+		pi := data.Viewer.Lists.PageInfo
+		if !pi.HasNextPage {
+			return lists, nil
+		}
+		after = &pi.EndCursor
+	}
+}
+
+// ResolveListID turns a list reference into a node ID. References that are
+// already node IDs (or the "starred" pseudo list) are returned unchanged;
+// anything else is matched against the viewer's lists by slug or,
+// case-insensitively, by name.
+func (c *Client) ResolveListID(ctx context.Context, ref string) (string, error) {
+	if ref == StarredListID || strings.HasPrefix(ref, "UL_") {
+		return ref, nil
+	}
+	lists, err := c.FetchLists(ctx)
+	if err != nil {
+		return "", fmt.Errorf("resolving star list %q: %w", ref, err)
+	}
+	for _, l := range lists {
+		if l.Slug == ref || strings.EqualFold(l.Name, ref) {
+			return l.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no star list named %q (run `star-watch lists` to see yours)", ref)
+}
+
+// FetchStarredPage returns one page of the viewer's starred repos, newest
+// first, with StarredAt set on each repo. Pass nil for after to start from
+// the most recent star.
+func (c *Client) FetchStarredPage(ctx context.Context, after *string) (*Page, error) {
+	vars := map[string]any{}
+	if after != nil {
+		vars["after"] = *after
+	}
+	return c.fetchAdaptive(ctx, vars, "first", func(v map[string]any) (*Page, error) {
+		return c.fetchStarredPage(ctx, v)
+	})
+}
+
+func (c *Client) fetchStarredPage(ctx context.Context, vars map[string]any) (*Page, error) {
+	body, err := c.doPageQuery(ctx, starredQuery, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var data starredData
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	conn := data.Viewer.StarredRepositories
+	nodes := make([]repoNode, 0, len(conn.Edges))
+	repos := make([]models.Repo, 0, len(conn.Edges))
+	for _, edge := range conn.Edges {
+		r := nodeToRepo(edge.Node)
+		starredAt := edge.StarredAt
+		r.StarredAt = &starredAt
+		nodes = append(nodes, edge.Node)
+		repos = append(repos, r)
+	}
+	if err := c.fetchReadmes(ctx, nodes, repos); err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		TotalCount: conn.TotalCount,
+		PageInfo:   conn.PageInfo,
+		Repos:      repos,
+	}, nil
 }
 
 // --- internal ---
@@ -109,9 +392,32 @@ type graphqlRequest struct {
 
 type graphqlResponse struct {
 	Data   json.RawMessage `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// notFoundError is returned when every GraphQL error is NOT_FOUND. The
+// partial data is kept for callers that look up many objects at once and can
+// tolerate some of them missing (their fields are null).
+type notFoundError struct {
+	err  error
+	data json.RawMessage
+}
+
+func (e *notFoundError) Error() string { return e.err.Error() }
+func (e *notFoundError) Unwrap() error { return e.err }
+
+func allNotFound(errs []graphqlError) bool {
+	for _, e := range errs {
+		if e.Type != "NOT_FOUND" {
+			return false
+		}
+	}
+	return len(errs) > 0
 }
 
 type starListData struct {
@@ -124,15 +430,46 @@ type starListData struct {
 	} `json:"node"`
 }
 
+type starredData struct {
+	Viewer struct {
+		StarredRepositories struct {
+			TotalCount int      `json:"totalCount"`
+			PageInfo   PageInfo `json:"pageInfo"`
+			Edges      []struct {
+				StarredAt time.Time `json:"starredAt"`
+				Node      repoNode  `json:"node"`
+			} `json:"edges"`
+		} `json:"starredRepositories"`
+	} `json:"viewer"`
+}
+
 type repoNode struct {
-	Owner struct {
+	ID         string `json:"id"`
+	DatabaseID int64  `json:"databaseId"`
+	Owner      struct {
 		Login string `json:"login"`
 	} `json:"owner"`
-	Name            string  `json:"name"`
-	Description     *string `json:"description"`
-	URL             string  `json:"url"`
-	HomepageURL     *string `json:"homepageUrl"`
-	StargazerCount  int     `json:"stargazerCount"`
+	Name           string     `json:"name"`
+	Description    *string    `json:"description"`
+	URL            string     `json:"url"`
+	HomepageURL    *string    `json:"homepageUrl"`
+	StargazerCount int        `json:"stargazerCount"`
+	ForkCount      int        `json:"forkCount"`
+	IsArchived     bool       `json:"isArchived"`
+	IsFork         bool       `json:"isFork"`
+	CreatedAt      *time.Time `json:"createdAt"`
+	PushedAt       *time.Time `json:"pushedAt"`
+	LicenseInfo    *struct {
+		SpdxID string `json:"spdxId"`
+		Name   string `json:"name"`
+	} `json:"licenseInfo"`
+	Issues struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"issues"`
+	LatestRelease *struct {
+		TagName     string     `json:"tagName"`
+		PublishedAt *time.Time `json:"publishedAt"`
+	} `json:"latestRelease"`
 	PrimaryLanguage *struct {
 		Name string `json:"name"`
 	} `json:"primaryLanguage"`
@@ -143,13 +480,19 @@ type repoNode struct {
 			} `json:"topic"`
 		} `json:"nodes"`
 	} `json:"repositoryTopics"`
-	Object *struct {
-		Text string `json:"text"`
-	} `json:"object"`
+	ReadmeRoot *readmeTree `json:"readmeRoot"`
+	ReadmeDocs *readmeTree `json:"readmeDocs"`
 }
 
-func (c *Client) fetchPage(ctx context.Context, vars map[string]any) (*Page, error) {
-	body, err := c.doGraphQL(ctx, pageQuery, vars)
+// fetchPage fetches one page of a list. With adaptive, too-large failures
+// are returned at once for fetchAdaptive to shrink the page; otherwise they
+// are retried like any transient failure.
+func (c *Client) fetchPage(ctx context.Context, vars map[string]any, adaptive bool) (*Page, error) {
+	do := c.doGraphQL
+	if adaptive {
+		do = c.doPageQuery
+	}
+	body, err := do(ctx, pageQuery, vars)
 	if err != nil {
 		return nil, err
 	}
@@ -163,6 +506,9 @@ func (c *Client) fetchPage(ctx context.Context, vars map[string]any) (*Page, err
 	for _, node := range data.Node.Items.Nodes {
 		repos = append(repos, nodeToRepo(node))
 	}
+	if err := c.fetchReadmes(ctx, data.Node.Items.Nodes, repos); err != nil {
+		return nil, err
+	}
 
 	return &Page{
 		TotalCount: data.Node.Items.TotalCount,
@@ -171,13 +517,43 @@ func (c *Client) fetchPage(ctx context.Context, vars map[string]any) (*Page, err
 	}, nil
 }
 
+// doGraphQL executes a query, retrying transient failures (5xx, network
+// errors, timeouts, primary and secondary rate limits) with backoff. See
+// retry.go.
 func (c *Client) doGraphQL(ctx context.Context, query string, variables map[string]any) (json.RawMessage, error) {
 	reqBody, err := json.Marshal(graphqlRequest{Query: query, Variables: variables})
 	if err != nil {
 		return nil, fmt.Errorf("marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, graphqlEndpoint, bytes.NewReader(reqBody))
+	return c.doWithRetry(ctx, func() ([]byte, error) {
+		data, err := c.doGraphQLOnce(ctx, reqBody)
+		// Outside paginated fetches there is no page to shrink, so a
+		// timeout is just a transient failure.
+		var tl *tooLargeError
+		if errors.As(err, &tl) {
+			return nil, &retryError{err: err}
+		}
+		return data, err
+	})
+}
+
+// doPageQuery is doGraphQL for paginated fetches: too-large failures are
+// returned without retrying, since fetchAdaptive answers them with a
+// smaller page.
+func (c *Client) doPageQuery(ctx context.Context, query string, variables map[string]any) (json.RawMessage, error) {
+	reqBody, err := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	return c.doWithRetry(ctx, func() ([]byte, error) {
+		return c.doGraphQLOnce(ctx, reqBody)
+	})
+}
+
+func (c *Client) doGraphQLOnce(ctx context.Context, reqBody []byte) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -186,17 +562,27 @@ func (c *Client) doGraphQL(ctx context.Context, query string, variables map[stri
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &retryError{err: fmt.Errorf("executing request: %w", err)}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if remaining, reset, ok := parseRemaining(resp.Header); ok {
+		c.limiter.update(remaining, reset)
+	}
+
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+		return nil, &retryError{err: fmt.Errorf("reading response: %w", err)}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(respBody))
+		if resp.StatusCode >= 500 && isTooLargeMessage(string(respBody)) {
+			return nil, &tooLargeError{err: fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(respBody))}
+		}
+		return nil, classifyHTTPError(resp, respBody)
 	}
 
 	var gqlResp graphqlResponse
@@ -204,27 +590,76 @@ func (c *Client) doGraphQL(ctx context.Context, query string, variables map[stri
 		return nil, fmt.Errorf("parsing GraphQL response: %w", err)
 	}
 	if len(gqlResp.Errors) > 0 {
-		return nil, fmt.Errorf("GraphQL error: %s", gqlResp.Errors[0].Message)
+		e := gqlResp.Errors[0]
+		err := fmt.Errorf("GraphQL error: %s", e.Message)
+		switch {
+		case e.Type == "RATE_LIMITED":
+			return nil, &retryError{err: err, wait: rateLimitWait(resp.Header, time.Now())}
+		case e.Type == "RESOURCE_LIMITS_EXCEEDED" || isTooLargeMessage(e.Message):
+			return nil, &tooLargeError{err: err}
+		case allNotFound(gqlResp.Errors):
+			return nil, &notFoundError{err: err, data: gqlResp.Data}
+		}
+		return nil, err
+	}
+
+	// Prefer the rateLimit object when the query asked for it; it reflects
+	// the GraphQL point budget rather than the REST-style headers.
+	var rl struct {
+		RateLimit *struct {
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+	}
+	if err := json.Unmarshal(gqlResp.Data, &rl); err == nil && rl.RateLimit != nil {
+		c.limiter.update(rl.RateLimit.Remaining, rl.RateLimit.ResetAt)
 	}
 
 	return gqlResp.Data, nil
 }
 
 func nodeToRepo(n repoNode) models.Repo {
+	now := time.Now().UTC()
 	r := models.Repo{
+		NodeID:      n.ID,
+		DatabaseID:  n.DatabaseID,
+		FetchedAt:   &now,
 		Owner:       n.Owner.Login,
 		Name:        n.Name,
 		FullName:    n.Owner.Login + "/" + n.Name,
 		Description: n.Description,
 		URL:         n.URL,
 		HomepageURL: n.HomepageURL,
+		Host:        hostOf(n.URL),
 		Stars:       n.StargazerCount,
+		Forks:       n.ForkCount,
+		OpenIssues:  n.Issues.TotalCount,
+		Archived:    n.IsArchived,
+		Fork:        n.IsFork,
+		CreatedAt:   n.CreatedAt,
+		PushedAt:    n.PushedAt,
 	}
 
 	if n.PrimaryLanguage != nil {
 		r.Language = &n.PrimaryLanguage.Name
 	}
 
+	// GitHub reports unrecognized licenses as spdxId "NOASSERTION".
+	if l := n.LicenseInfo; l != nil {
+		license := l.SpdxID
+		if license == "" || license == "NOASSERTION" {
+			license = l.Name
+		}
+		if license != "" {
+			r.License = &license
+		}
+	}
+
+	if rel := n.LatestRelease; rel != nil && rel.TagName != "" {
+		r.LatestRelease = &rel.TagName
+		r.LatestReleaseAt = rel.PublishedAt
+	}
+
 	var topics []string
 	for _, t := range n.RepositoryTopics.Nodes {
 		topics = append(topics, t.Topic.Name)
@@ -234,14 +669,14 @@ func nodeToRepo(n repoNode) models.Repo {
 	}
 	r.Topics = topics
 
-	if n.Object != nil && n.Object.Text != "" {
-		text := n.Object.Text
-		const maxLen = 3000
-		if len(text) > maxLen {
-			text = text[:maxLen]
-		}
-		r.ReadmeExcerpt = &text
-	}
-
 	return r
 }
+
+// hostOf returns the host of a repo URL, e.g. "github.com".
+func hostOf(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+// lookupBatchSize is how many repos are looked up per query via aliased
+// repository(owner:, name:) fields.
+const lookupBatchSize = 25
+
+// RepoRef names a repository by owner and name.
+type RepoRef struct {
+	Owner string
+	Name  string
+}
+
+func (r RepoRef) FullName() string { return r.Owner + "/" + r.Name }
+
+// lookupRepos runs one aliased repository() lookup per ref, selecting the
+// named fragment (whose definition is appended to the query), and returns
+// the raw JSON of each result in ref order. Repos that were deleted or are
+// inaccessible come back as nil rather than failing the batch.
+func (c *Client) lookupRepos(ctx context.Context, refs []RepoRef, fragmentName, fragment string) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, 0, len(refs))
+	for start := 0; start < len(refs); start += lookupBatchSize {
+		end := min(start+lookupBatchSize, len(refs))
+		batch, err := c.lookupBatch(ctx, refs[start:end], fragmentName, fragment)
+		if err != nil {
+			return nil, fmt.Errorf("looking up repos %d-%d: %w", start, end, err)
+		}
+		out = append(out, batch...)
+	}
+	return out, nil
+}
+
+func (c *Client) lookupBatch(ctx context.Context, batch []RepoRef, fragmentName, fragment string) ([]json.RawMessage, error) {
+	var params, fields []string
+	vars := map[string]any{}
+	for i, r := range batch {
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		fields = append(fields, fmt.Sprintf("  r%d: repository(owner: $o%d, name: $n%d) { ...%s }", i, i, i, fragmentName))
+		vars[fmt.Sprintf("o%d", i)] = r.Owner
+		vars[fmt.Sprintf("n%d", i)] = r.Name
+	}
+	query := fmt.Sprintf("query(%s) {\n  rateLimit { remaining resetAt }\n%s\n}\n",
+		strings.Join(params, ", "), strings.Join(fields, "\n")) + fragment
+
+	// Renamed-away or deleted repos resolve to null with a NOT_FOUND error;
+	// keep the rest of the batch.
+	body, err := c.doGraphQL(ctx, query, vars)
+	var nf *notFoundError
+	if errors.As(err, &nf) {
+		body, err = nf.data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	out := make([]json.RawMessage, len(batch))
+	for i := range batch {
+		if raw, ok := data[fmt.Sprintf("r%d", i)]; ok && string(raw) != "null" {
+			out[i] = raw
+		}
+	}
+	return out, nil
+}
+
+const repoIDFields = `
+fragment RepoIDFields on Repository {
+  id
+  databaseId
+  nameWithOwner
+}
+`
+
+// RepoIDs are the stable identifiers of a repository.
+type RepoIDs struct {
+	NodeID     string
+	DatabaseID int64
+	FullName   string // current owner/name, which differs after a rename
+}
+
+// LookupIDs resolves node and database IDs for repos by owner/name. GitHub
+// follows renames and transfers, so FullName reports the current name. Repos
+// that no longer exist are absent from the result.
+func (c *Client) LookupIDs(ctx context.Context, refs []RepoRef) (map[RepoRef]RepoIDs, error) {
+	raws, err := c.lookupRepos(ctx, refs, "RepoIDFields", repoIDFields)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[RepoRef]RepoIDs, len(refs))
+	for i, raw := range raws {
+		if raw == nil {
+			continue
+		}
+		var node struct {
+			ID            string `json:"id"`
+			DatabaseID    int64  `json:"databaseId"`
+			NameWithOwner string `json:"nameWithOwner"`
+		}
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return nil, fmt.Errorf("parsing IDs for %s: %w", refs[i].FullName(), err)
+		}
+		out[refs[i]] = RepoIDs{NodeID: node.ID, DatabaseID: node.DatabaseID, FullName: node.NameWithOwner}
+	}
+	return out, nil
+}
+
+const repoCountFields = `
+fragment RepoCountFields on Repository {
+  stargazerCount
+  forkCount
+  issues(states: OPEN) { totalCount }
+}
+`
+
+// RepoCounts are the activity counts tracked in star history.
+type RepoCounts struct {
+	Stars      int
+	Forks      int
+	OpenIssues int
+}
+
+// LookupCounts fetches current star, fork and open issue counts for repos
+// by owner/name. It is much cheaper than FetchRepos, for refreshing cached
+// repos. Repos that no longer exist are absent from the result.
+func (c *Client) LookupCounts(ctx context.Context, refs []RepoRef) (map[RepoRef]RepoCounts, error) {
+	raws, err := c.lookupRepos(ctx, refs, "RepoCountFields", repoCountFields)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[RepoRef]RepoCounts, len(refs))
+	for i, raw := range raws {
+		if raw == nil {
+			continue
+		}
+		var node struct {
+			StargazerCount int `json:"stargazerCount"`
+			ForkCount      int `json:"forkCount"`
+			Issues         struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"issues"`
+		}
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return nil, fmt.Errorf("parsing counts for %s: %w", refs[i].FullName(), err)
+		}
+		out[refs[i]] = RepoCounts{Stars: node.StargazerCount, Forks: node.ForkCount, OpenIssues: node.Issues.TotalCount}
+	}
+	return out, nil
+}
+
+// FetchRepos looks up full repo metadata (the same fields as a list page)
+// by owner/name. Repos that don't exist or aren't accessible are skipped.
+func (c *Client) FetchRepos(ctx context.Context, refs []RepoRef) ([]models.Repo, error) {
+	raws, err := c.lookupRepos(ctx, refs, "RepoFields", repoFields)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]repoNode, 0, len(refs))
+	repos := make([]models.Repo, 0, len(refs))
+	for i, raw := range raws {
+		if raw == nil {
+			continue
+		}
+		var node repoNode
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", refs[i].FullName(), err)
+		}
+		nodes = append(nodes, node)
+		repos = append(repos, nodeToRepo(node))
+	}
+	if err := c.fetchReadmes(ctx, nodes, repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
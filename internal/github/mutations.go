@@ -0,0 +1,139 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const listItemsQuery = `
+query($listId: ID!, $after: String) {
+  rateLimit { remaining resetAt }
+  node(id: $listId) {
+    ... on UserList {
+      items(first: 100, after: $after) {
+        nodes { ... on Repository { id } }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}
+`
+
+// FetchListItemIDs returns the node IDs of every repo in a star list. It is
+// much cheaper than a full page fetch when only membership is needed.
+func (c *Client) FetchListItemIDs(ctx context.Context, listID string) ([]string, error) {
+	var ids []string
+	vars := map[string]any{"listId": listID}
+	for {
+		body, err := c.doGraphQL(ctx, listItemsQuery, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		var data struct {
+			Node *struct {
+				Items struct {
+					Nodes []struct {
+						ID string `json:"id"`
+					} `json:"nodes"`
+					PageInfo PageInfo `json:"pageInfo"`
+				} `json:"items"`
+			} `json:"node"`
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		if data.Node == nil {
+			return nil, fmt.Errorf("star list %s not found", listID)
+		}
+
+		for _, n := range data.Node.Items.Nodes {
+			if n.ID != "" {
+				ids = append(ids, n.ID)
+			}
+		}
+		if !data.Node.Items.PageInfo.HasNextPage {
+			return ids, nil
+		}
+		vars["after"] = data.Node.Items.PageInfo.EndCursor
+	}
+}
+
+const createListMutation = `
+mutation($name: String!, $description: String, $isPrivate: Boolean!) {
+  createUserList(input: {name: $name, description: $description, isPrivate: $isPrivate}) {
+    list { id name slug }
+  }
+}
+`
+
+// CreateList creates a star list for the viewer. It is not retried: a
+// request that timed out may still have created the list.
+func (c *Client) CreateList(ctx context.Context, name, description string, private bool) (*List, error) {
+	body, err := c.doMutation(ctx, createListMutation, map[string]any{
+		"name":        name,
+		"description": description,
+		"isPrivate":   private,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating star list %q: %w", name, err)
+	}
+
+	var data struct {
+		CreateUserList struct {
+			List *struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Slug string `json:"slug"`
+			} `json:"list"`
+		} `json:"createUserList"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	l := data.CreateUserList.List
+	if l == nil || l.ID == "" {
+		return nil, fmt.Errorf("creating star list %q: no list returned", name)
+	}
+	return &List{ID: l.ID, Name: l.Name, Slug: l.Slug, Description: &description}, nil
+}
+
+const updateListsMutation = `
+mutation($itemId: ID!, $listIds: [ID!]!) {
+  updateUserListsForItem(input: {itemId: $itemId, listIds: $listIds}) {
+    clientMutationId
+  }
+}
+`
+
+// SetListsForItem sets the star lists a repo belongs to. GitHub replaces the
+// repo's memberships with listIDs, so callers adding a repo to a list must
+// pass the lists it is already in as well.
+func (c *Client) SetListsForItem(ctx context.Context, repoNodeID string, listIDs []string) error {
+	if listIDs == nil {
+		listIDs = []string{}
+	}
+	_, err := c.doMutation(ctx, updateListsMutation, map[string]any{
+		"itemId":  repoNodeID,
+		"listIds": listIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("updating lists for %s: %w", repoNodeID, err)
+	}
+	return nil
+}
+
+// doMutation sends a mutation once, after waiting out the rate limit. Unlike
+// doGraphQL it never retries, since a failed response doesn't tell whether
+// GitHub applied the mutation.
+func (c *Client) doMutation(ctx context.Context, mutation string, variables map[string]any) (json.RawMessage, error) {
+	reqBody, err := json.Marshal(graphqlRequest{Query: mutation, Variables: variables})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+	if err := c.limiter.wait(ctx, c.sleep); err != nil {
+		return nil, err
+	}
+	return c.doGraphQLOnce(ctx, reqBody)
+}
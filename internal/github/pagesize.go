@@ -0,0 +1,112 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	maxPageSize = 100 // GitHub's connection limit
+	minPageSize = 5
+
+	// growAfter is the number of consecutive successful pages before the
+	// page size is allowed to grow again.
+	growAfter = 3
+)
+
+// errQueryTooLarge marks a failure caused by the size of the requested page
+// (GraphQL timeouts, resource limits). The fix is a smaller page, not a retry.
+var errQueryTooLarge = errors.New("query too large")
+
+// tooLargeError wraps a page-size related failure so it matches
+// errQueryTooLarge while keeping the original message.
+type tooLargeError struct{ err error }
+
+func (e *tooLargeError) Error() string        { return e.err.Error() }
+func (e *tooLargeError) Unwrap() error        { return e.err }
+func (e *tooLargeError) Is(target error) bool { return target == errQueryTooLarge }
+
+// isTooLargeMessage reports whether a GraphQL error or 5xx body indicates
+// that the query was too expensive to finish.
+func isTooLargeMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, s := range []string{
+		"resource limits",
+		"timeout",
+		"timed out",
+		"couldn't respond to your request in time",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageSizer adapts the number of items requested per page: it halves on
+// too-large failures and doubles after growAfter consecutive successes.
+type pageSizer struct {
+	mu        sync.Mutex
+	size      int
+	successes int
+}
+
+func newPageSizer() *pageSizer {
+	return &pageSizer{size: maxPageSize}
+}
+
+func (p *pageSizer) current() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size
+}
+
+func (p *pageSizer) succeeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.successes++
+	if p.successes >= growAfter && p.size < maxPageSize {
+		p.size = min(p.size*2, maxPageSize)
+		p.successes = 0
+	}
+}
+
+// shrink halves the page size. It returns false if already at the minimum.
+func (p *pageSizer) shrink() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.successes = 0
+	if p.size <= minPageSize {
+		return false
+	}
+	p.size = max(p.size/2, minPageSize)
+	return true
+}
+
+// fetchAdaptive runs fetch with the current page size stored under sizeKey
+// in vars, shrinking and retrying on too-large errors. Cursors in vars are
+// left untouched, so a retry resumes from exactly the same position and the
+// returned page's cursors remain valid for the next call whatever its size.
+func (c *Client) fetchAdaptive(ctx context.Context, vars map[string]any, sizeKey string, fetch func(map[string]any) (*Page, error)) (*Page, error) {
+	for {
+		size := c.pageSize.current()
+		vars[sizeKey] = size
+
+		page, err := fetch(vars)
+		if err == nil {
+			page.PageSize = size
+			c.pageSize.succeeded()
+			return page, nil
+		}
+		if !errors.Is(err, errQueryTooLarge) || !c.pageSize.shrink() {
+			return nil, err
+		}
+		fmt.Printf("  WARN: page of %d too large (%v), retrying with %d\n", size, err, c.pageSize.current())
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestPageSizer(t *testing.T) {
+	p := newPageSizer()
+	var sizes []int
+	for p.shrink() {
+		sizes = append(sizes, p.current())
+	}
+	if want := []int{50, 25, 12, 6, 5}; !slices.Equal(sizes, want) {
+		t.Errorf("shrinking gave %v, want %v", sizes, want)
+	}
+	if p.current() != minPageSize {
+		t.Fatalf("size = %d after shrinking, want %d", p.current(), minPageSize)
+	}
+
+	// Growth needs growAfter successes in a row; a shrink resets the count.
+	p.succeeded()
+	p.succeeded()
+	p.shrink()
+	for i := 0; i < growAfter-1; i++ {
+		p.succeeded()
+	}
+	if p.current() != minPageSize {
+		t.Errorf("size = %d before %d successes, want %d", p.current(), growAfter, minPageSize)
+	}
+	p.succeeded()
+	if p.current() != 2*minPageSize {
+		t.Errorf("size = %d after %d successes, want %d", p.current(), growAfter, 2*minPageSize)
+	}
+
+	for i := 0; i < 10*growAfter; i++ {
+		p.succeeded()
+	}
+	if p.current() != maxPageSize {
+		t.Errorf("size = %d after many successes, want the cap %d", p.current(), maxPageSize)
+	}
+}
+
+// pagedServer serves a list of n repos named "o/rN" with forward cursors,
+// failing every page larger than limit. timeout picks how: a 502 timeout
+// page or a RESOURCE_LIMITS_EXCEEDED GraphQL error. It records the page
+// size of every request.
+func pagedServer(t *testing.T, n, limit int, timeout bool) (*Client, *[]int) {
+	t.Helper()
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		first := int(req.Variables["first"].(float64))
+		sizes = append(sizes, first)
+
+		if first > limit {
+			if timeout {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte(`{"message": "We couldn't respond to your request in time."}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"errors": []map[string]any{{"type": "RESOURCE_LIMITS_EXCEEDED", "message": "Resource limits for this query exceeded."}},
+			})
+			return
+		}
+
+		start := 0
+		if after, ok := req.Variables["after"].(string); ok {
+			start, _ = strconv.Atoi(after)
+		}
+		end := min(start+first, n)
+		var nodes []map[string]any
+		for i := start; i < end; i++ {
+			nodes = append(nodes, map[string]any{
+				"id":    fmt.Sprintf("R%d", i),
+				"owner": map[string]any{"login": "o"},
+				"name":  fmt.Sprintf("r%d", i),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"node": map[string]any{
+					"items": map[string]any{
+						"totalCount": n,
+						"pageInfo":   map[string]any{"hasNextPage": end < n, "endCursor": strconv.Itoa(end)},
+						"nodes":      nodes,
+					},
+				},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, "token", srv.Client()), &sizes
+}
+
+func TestFetchAdaptive(t *testing.T) {
+	for _, timeout := range []bool{true, false} {
+		name := "resource limits"
+		if timeout {
+			name = "timeout"
+		}
+		t.Run(name, func(t *testing.T) {
+			const n = 130
+			c, sizes := pagedServer(t, n, 30, timeout)
+
+			var got []string
+			var after *string
+			for {
+				page, err := c.FetchPageForward(context.Background(), "UL_x", after)
+				if err != nil {
+					t.Fatalf("FetchPageForward: %v", err)
+				}
+				if page.PageSize != 25 {
+					t.Errorf("PageSize = %d, want the size that succeeded (25)", page.PageSize)
+				}
+				for _, r := range page.Repos {
+					got = append(got, r.NodeID)
+				}
+				if !page.PageInfo.HasNextPage {
+					break
+				}
+				cursor := page.PageInfo.EndCursor
+				after = &cursor
+			}
+
+			// 100 and 50 fail, then 25 succeeds three times and the size
+			// grows back to 50, which fails again.
+			want := []int{100, 50, 25, 25, 25, 50, 25, 25, 25}
+			if !slices.Equal(*sizes, want) {
+				t.Errorf("requested sizes %v, want %v", *sizes, want)
+			}
+			if len(got) != n {
+				t.Fatalf("got %d repos, want %d", len(got), n)
+			}
+			for i, id := range got {
+				if want := fmt.Sprintf("R%d", i); id != want {
+					t.Fatalf("repo %d = %s, want %s (skipped or duplicated)", i, id, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchAdaptiveGivesUpAtMinimum(t *testing.T) {
+	c, sizes := pagedServer(t, 10, minPageSize-1, false)
+
+	_, err := c.FetchPageForward(context.Background(), "UL_x", nil)
+	if !errors.Is(err, errQueryTooLarge) {
+		t.Fatalf("err = %v, want errQueryTooLarge", err)
+	}
+	if want := []int{100, 50, 25, 12, 6, 5}; !slices.Equal(*sizes, want) {
+		t.Errorf("requested sizes %v, want %v", *sizes, want)
+	}
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	"github.com/kevinmichaelchen/star-watch/internal/readme"
+)
+
+// readmeCandidates lists README paths in priority order. repoFields only
+// lists the entries of the root and docs/ trees at HEAD; the first
+// candidate present there as a non-empty blob is then fetched on its own by
+// fetchReadmes, so each repo costs at most one blob.
+var readmeCandidates = []string{
+	"README.md",
+	"readme.md",
+	"Readme.md",
+	"README.markdown",
+	"README.rst",
+	"README.txt",
+	"README",
+	"docs/README.md",
+}
+
+// readmeFields is the part of repoFields that lists README candidates.
+const readmeFields = `  readmeRoot: object(expression: "HEAD:") {
+    ... on Tree { ...ReadmeEntries }
+  }
+  readmeDocs: object(expression: "HEAD:docs") {
+    ... on Tree { ...ReadmeEntries }
+  }
+`
+
+const readmeEntriesFragment = `
+fragment ReadmeEntries on Tree {
+  entries {
+    name
+    object { ... on Blob { id byteSize } }
+  }
+}
+`
+
+type readmeTree struct {
+	Entries []struct {
+		Name   string `json:"name"`
+		Object *struct {
+			ID       string `json:"id"`
+			ByteSize int    `json:"byteSize"`
+		} `json:"object"`
+	} `json:"entries"`
+}
+
+// pickReadme returns the path and blob node ID of the first README
+// candidate that exists and isn't empty, or empty strings if there is none.
+func (n repoNode) pickReadme() (string, string) {
+	blobs := map[string]string{}
+	add := func(dir string, t *readmeTree) {
+		if t == nil {
+			return
+		}
+		for _, e := range t.Entries {
+			if e.Object != nil && e.Object.ID != "" && e.Object.ByteSize > 0 {
+				blobs[path.Join(dir, e.Name)] = e.Object.ID
+			}
+		}
+	}
+	add("", n.ReadmeRoot)
+	add("docs", n.ReadmeDocs)
+
+	for _, p := range readmeCandidates {
+		if id, ok := blobs[p]; ok {
+			return p, id
+		}
+	}
+	return "", ""
+}
+
+// readmeBatchSize is how many README blobs are fetched per nodes() query.
+const readmeBatchSize = 50
+
+const readmeQuery = `
+query($ids: [ID!]!) {
+  rateLimit { remaining resetAt }
+  nodes(ids: $ids) {
+    ... on Blob { id text }
+  }
+}
+`
+
+// fetchReadmes fetches the README picked for each node and stores its path,
+// length and cleaned excerpt on the repo at the same index.
+func (c *Client) fetchReadmes(ctx context.Context, nodes []repoNode, repos []models.Repo) error {
+	paths := map[string]string{}
+	targets := map[string][]int{}
+	var ids []string
+	for i, n := range nodes {
+		p, id := n.pickReadme()
+		if id == "" {
+			continue
+		}
+		if _, ok := targets[id]; !ok {
+			ids = append(ids, id)
+		}
+		paths[id] = p
+		targets[id] = append(targets[id], i)
+	}
+
+	for start := 0; start < len(ids); start += readmeBatchSize {
+		end := min(start+readmeBatchSize, len(ids))
+		body, err := c.doGraphQL(ctx, readmeQuery, map[string]any{"ids": ids[start:end]})
+		if err != nil {
+			return fmt.Errorf("fetching READMEs: %w", err)
+		}
+		var data struct {
+			Nodes []*struct {
+				ID   string `json:"id"`
+				Text string `json:"text"`
+			} `json:"nodes"`
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return fmt.Errorf("parsing READMEs: %w", err)
+		}
+
+		for _, b := range data.Nodes {
+			// Binary blobs have no text; treat them like a missing README.
+			if b == nil || b.Text == "" {
+				continue
+			}
+			p := paths[b.ID]
+			for _, i := range targets[b.ID] {
+				setReadme(&repos[i], p, b.Text)
+			}
+		}
+	}
+	return nil
+}
+
+func setReadme(r *models.Repo, p, text string) {
+	rawLen := len(text)
+	r.ReadmePath = &p
+	r.ReadmeLength = &rawLen
+
+	const maxLen = 3000
+	if excerpt := readme.Excerpt(text, maxLen); excerpt != "" {
+		r.ReadmeExcerpt = &excerpt
+	}
+}
@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+// tree builds a readmeTree from "name=id:size" entries; entries without an
+// id are subtrees.
+func tree(entries ...string) *readmeTree {
+	raw := `{"entries": [`
+	for i, e := range entries {
+		if i > 0 {
+			raw += ","
+		}
+		name, blob, ok := strings.Cut(e, "=")
+		if !ok {
+			raw += `{"name": "` + name + `", "object": {}}`
+			continue
+		}
+		id, size, _ := strings.Cut(blob, ":")
+		raw += `{"name": "` + name + `", "object": {"id": "` + id + `", "byteSize": ` + size + `}}`
+	}
+	raw += `]}`
+	var t readmeTree
+	if err := json.Unmarshal([]byte(raw), &t); err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestPickReadme(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     *readmeTree
+		docs     *readmeTree
+		wantPath string
+		wantID   string
+	}{
+		{"none", tree("main.go=B1:10", "src"), nil, "", ""},
+		{"empty repo", nil, nil, "", ""},
+		{"README.md", tree("main.go=B1:10", "README.md=B2:10"), nil, "README.md", "B2"},
+		{"priority", tree("README.rst=B1:10", "readme.md=B2:10"), nil, "readme.md", "B2"},
+		{"empty file skipped", tree("README.md=B1:0", "README.txt=B2:10"), nil, "README.txt", "B2"},
+		{"directory named README", tree("README", "README.txt=B2:10"), nil, "README.txt", "B2"},
+		{"docs", tree("docs"), tree("README.md=B3:10"), "docs/README.md", "B3"},
+		{"root wins over docs", tree("README=B1:10"), tree("README.md=B3:10"), "README", "B1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := repoNode{ReadmeRoot: tt.root, ReadmeDocs: tt.docs}
+			path, id := n.pickReadme()
+			if path != tt.wantPath || id != tt.wantID {
+				t.Errorf("pickReadme() = %q, %q; want %q, %q", path, id, tt.wantPath, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestFetchReadmes(t *testing.T) {
+	var requested [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				IDs []string `json:"ids"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		requested = append(requested, req.Variables.IDs)
+		texts := map[string]any{
+			"B1": map[string]any{"id": "B1", "text": "# One\n\nThe first repo."},
+			"B2": map[string]any{"id": "B2", "text": nil}, // binary
+		}
+		var nodes []any
+		for _, id := range req.Variables.IDs {
+			nodes = append(nodes, texts[id])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"nodes": nodes}})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, "token", srv.Client())
+
+	nodes := []repoNode{
+		{ReadmeRoot: tree("README.md=B1:22", "README.rst=B9:10")},
+		{ReadmeRoot: tree("main.go=B5:10")},
+		{ReadmeRoot: tree("README=B2:10")},
+	}
+	repos := make([]models.Repo, len(nodes))
+	if err := c.fetchReadmes(context.Background(), nodes, repos); err != nil {
+		t.Fatalf("fetchReadmes: %v", err)
+	}
+
+	if len(requested) != 1 || strings.Join(requested[0], ",") != "B1,B2" {
+		t.Errorf("requested blobs %v, want [[B1 B2]]", requested)
+	}
+	if r := repos[0]; r.ReadmePath == nil || *r.ReadmePath != "README.md" ||
+		r.ReadmeLength == nil || *r.ReadmeLength != 22 || r.ReadmeExcerpt == nil {
+		t.Errorf("repo 0 = %+v, want README.md with an excerpt", r)
+	}
+	for _, i := range []int{1, 2} {
+		if r := repos[i]; r.ReadmePath != nil || r.ReadmeExcerpt != nil {
+			t.Errorf("repo %d = %+v, want no README", i, r)
+		}
+	}
+}
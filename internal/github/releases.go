@@ -0,0 +1,98 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	"github.com/kevinmichaelchen/star-watch/internal/readme"
+)
+
+const (
+	// releasesPerRepo is how many of each repo's most recent releases are
+	// fetched; enough to cover a few releases between syncs.
+	releasesPerRepo = 5
+
+	// maxNotesLen caps stored release notes, like README excerpts.
+	maxNotesLen = 2000
+)
+
+const releaseFields = `
+fragment ReleaseFields on Repository {
+  releases(first: %d, orderBy: {field: CREATED_AT, direction: DESC}) {
+    nodes {
+      tagName
+      name
+      url
+      publishedAt
+      isDraft
+      isPrerelease
+      description
+    }
+  }
+}
+`
+
+type releaseNode struct {
+	TagName      string     `json:"tagName"`
+	Name         *string    `json:"name"`
+	URL          string     `json:"url"`
+	PublishedAt  *time.Time `json:"publishedAt"`
+	IsDraft      bool       `json:"isDraft"`
+	IsPrerelease bool       `json:"isPrerelease"`
+	Description  *string    `json:"description"`
+}
+
+// FetchReleases returns the most recent releases of each repo, keyed by full
+// name. Repos are looked up by owner/name in batches, so this works for
+// cached repos that weren't refetched in this sync. Drafts are skipped and
+// notes are cleaned and truncated like README excerpts.
+func (c *Client) FetchReleases(ctx context.Context, repos []models.Repo) (map[string][]models.Release, error) {
+	refs := make([]RepoRef, len(repos))
+	for i, r := range repos {
+		refs[i] = RepoRef{Owner: r.Owner, Name: r.Name}
+	}
+	raws, err := c.lookupRepos(ctx, refs, "ReleaseFields", fmt.Sprintf(releaseFields, releasesPerRepo))
+	if err != nil {
+		return nil, fmt.Errorf("fetching releases: %w", err)
+	}
+
+	out := make(map[string][]models.Release, len(repos))
+	for i, raw := range raws {
+		if raw == nil {
+			continue // repo deleted or inaccessible
+		}
+		var node struct {
+			Releases struct {
+				Nodes []releaseNode `json:"nodes"`
+			} `json:"releases"`
+		}
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return nil, fmt.Errorf("parsing releases for %s: %w", repos[i].FullName, err)
+		}
+
+		var releases []models.Release
+		for _, n := range node.Releases.Nodes {
+			if n.IsDraft {
+				continue
+			}
+			rel := models.Release{
+				Tag:         n.TagName,
+				Name:        n.Name,
+				URL:         n.URL,
+				PublishedAt: n.PublishedAt,
+				Prerelease:  n.IsPrerelease,
+			}
+			if n.Description != nil {
+				if notes := readme.Excerpt(*n.Description, maxNotesLen); notes != "" {
+					rel.Notes = &notes
+				}
+			}
+			releases = append(releases, rel)
+		}
+		out[repos[i].FullName] = releases
+	}
+	return out, nil
+}
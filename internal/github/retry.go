@@ -0,0 +1,182 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 6
+	baseBackoff       = time.Second
+	maxBackoff        = time.Minute
+
+	// maxRateLimitWait caps how long we'll sleep for a rate limit reset.
+	// The primary limit resets hourly, so this covers the worst case.
+	maxRateLimitWait = time.Hour
+)
+
+// retryError marks a failure that is worth retrying. If wait is non-zero,
+// GitHub told us how long to back off (Retry-After or a rate limit reset);
+// otherwise exponential backoff applies.
+type retryError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+func (e *retryError) Unwrap() error { return e.err }
+
+// rateLimiter tracks the primary rate limit reported by GitHub so the client
+// can pause before a request that would fail, rather than after.
+type rateLimiter struct {
+	mu        sync.Mutex
+	remaining int // -1 until the first response
+	resetAt   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{remaining: -1}
+}
+
+// update records the latest remaining/reset values.
+func (l *rateLimiter) update(remaining int, resetAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remaining = remaining
+	l.resetAt = resetAt
+}
+
+// wait blocks in sleep until the limit resets if the budget is exhausted.
+func (l *rateLimiter) wait(ctx context.Context, sleep func(context.Context, time.Duration) error) error {
+	l.mu.Lock()
+	remaining, resetAt := l.remaining, l.resetAt
+	l.mu.Unlock()
+
+	if remaining != 0 || resetAt.IsZero() {
+		return nil
+	}
+	d := time.Until(resetAt)
+	if d <= 0 {
+		return nil
+	}
+	fmt.Printf("  Rate limit exhausted, waiting %s for reset\n", d.Round(time.Second))
+	return sleep(ctx, min(d, maxRateLimitWait))
+}
+
+// doWithRetry runs fn until it succeeds, returns a non-retryable error, or
+// runs out of attempts.
+func (c *Client) doWithRetry(ctx context.Context, fn func() ([]byte, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, c.sleep); err != nil {
+			return nil, err
+		}
+
+		data, err := fn()
+		if err == nil {
+			return data, nil
+		}
+
+		var re *retryError
+		if !errors.As(err, &re) || attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		wait := re.wait
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		fmt.Printf("  WARN: %v (retry %d/%d in %s)\n", err, attempt+1, c.maxRetries, wait.Round(time.Millisecond))
+		if err := c.sleep(ctx, min(wait, maxRateLimitWait)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns an exponentially growing delay with full jitter in its
+// upper half, capped at maxBackoff.
+func backoff(attempt int) time.Duration {
+	d := min(baseBackoff<<attempt, maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// classifyHTTPError wraps a non-200 response as retryable when it is a
+// server error or a primary/secondary rate limit.
+func classifyHTTPError(resp *http.Response, body []byte) error {
+	err := fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(body))
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && isRateLimited(resp, body):
+		return &retryError{err: err, wait: rateLimitWait(resp.Header, time.Now())}
+	case resp.StatusCode >= 500:
+		return &retryError{err: err, wait: retryAfter(resp.Header)}
+	default:
+		return err
+	}
+}
+
+func isRateLimited(resp *http.Response, body []byte) bool {
+	if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(string(body)), "rate limit")
+}
+
+// rateLimitWait returns how long GitHub asked us to wait: Retry-After if
+// present, else time until X-RateLimit-Reset when the budget is exhausted.
+// Zero means "use exponential backoff".
+func rateLimitWait(h http.Header, now time.Time) time.Duration {
+	if d := retryAfter(h); d > 0 {
+		return d
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if reset, ok := parseReset(h); ok && reset.After(now) {
+			return reset.Sub(now)
+		}
+	}
+	return 0
+}
+
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// parseRemaining reads X-RateLimit-Remaining and X-RateLimit-Reset.
+func parseRemaining(h http.Header) (int, time.Time, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	reset, _ := parseReset(h)
+	return remaining, reset, true
+}
+
+func parseReset(h http.Header) (time.Time, bool) {
+	epoch, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(epoch, 0), true
+}
@@ -0,0 +1,268 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClassifyHTTPError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		header    http.Header
+		body      string
+		retryable bool
+		wait      time.Duration
+	}{
+		{"server error", 502, nil, "Bad Gateway", true, 0},
+		{"server error with Retry-After", 503, http.Header{"Retry-After": {"5"}}, "", true, 5 * time.Second},
+		{"too many requests", 429, http.Header{"Retry-After": {"30"}}, "", true, 30 * time.Second},
+		{"too many requests without header", 429, nil, "", true, 0},
+		{"secondary rate limit", 403, nil, `{"message": "You have exceeded a secondary rate limit."}`, true, 0},
+		{"secondary rate limit with Retry-After", 403, http.Header{"Retry-After": {"60"}}, "", true, time.Minute},
+		{"forbidden", 403, nil, `{"message": "Resource not accessible by integration"}`, false, 0},
+		{"unauthorized", 401, nil, `{"message": "Bad credentials"}`, false, 0},
+		{"not found", 404, nil, "", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			err := classifyHTTPError(resp, []byte(tt.body))
+			var re *retryError
+			if got := errors.As(err, &re); got != tt.retryable {
+				t.Fatalf("retryable = %v, want %v (%v)", got, tt.retryable, err)
+			}
+			if re != nil && re.wait != tt.wait {
+				t.Errorf("wait = %v, want %v", re.wait, tt.wait)
+			}
+		})
+	}
+}
+
+func TestClassifyHTTPErrorPrimaryRateLimit(t *testing.T) {
+	reset := time.Now().Add(90 * time.Second)
+	resp := &http.Response{StatusCode: 403, Header: http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(reset.Unix(), 10)},
+	}}
+	var re *retryError
+	if !errors.As(classifyHTTPError(resp, []byte("API rate limit exceeded")), &re) {
+		t.Fatal("exhausted primary rate limit not retryable")
+	}
+	if re.wait <= 80*time.Second || re.wait > 90*time.Second {
+		t.Errorf("wait = %v, want about 90s until reset", re.wait)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	reset := strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10)
+	past := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"nothing", http.Header{}, 0},
+		{"Retry-After", http.Header{"Retry-After": {"7"}}, 7 * time.Second},
+		{"Retry-After wins over reset", http.Header{"Retry-After": {"7"}, "X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}}, 7 * time.Second},
+		{"exhausted", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {reset}}, 2 * time.Minute},
+		{"reset in the past", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {past}}, 0},
+		{"budget left", http.Header{"X-Ratelimit-Remaining": {"12"}, "X-Ratelimit-Reset": {reset}}, 0},
+		{"bad reset", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateLimitWait(tt.header, now); got != tt.want {
+				t.Errorf("rateLimitWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"1", time.Second},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"1.5", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		if got := retryAfter(h); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseRemaining(t *testing.T) {
+	tests := []struct {
+		name          string
+		remaining     string
+		reset         string
+		wantRemaining int
+		wantReset     time.Time
+		wantOK        bool
+	}{
+		{"both", "42", "1700000000", 42, time.Unix(1_700_000_000, 0), true},
+		{"exhausted", "0", "1700000000", 0, time.Unix(1_700_000_000, 0), true},
+		{"no reset", "42", "", 42, time.Time{}, true},
+		{"missing", "", "1700000000", 0, time.Time{}, false},
+		{"garbage", "lots", "1700000000", 0, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.remaining != "" {
+				h.Set("X-RateLimit-Remaining", tt.remaining)
+			}
+			if tt.reset != "" {
+				h.Set("X-RateLimit-Reset", tt.reset)
+			}
+			remaining, reset, ok := parseRemaining(h)
+			if remaining != tt.wantRemaining || !reset.Equal(tt.wantReset) || ok != tt.wantOK {
+				t.Errorf("parseRemaining() = %d, %v, %v; want %d, %v, %v",
+					remaining, reset, ok, tt.wantRemaining, tt.wantReset, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt <= defaultMaxRetries; attempt++ {
+		ceiling := min(baseBackoff<<attempt, maxBackoff)
+		for i := 0; i < 100; i++ {
+			if d := backoff(attempt); d < ceiling/2 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", attempt, d, ceiling/2, ceiling)
+			}
+		}
+	}
+}
+
+// scriptedServer answers successive requests with the given responses and
+// returns a client whose sleeps are recorded instead of taken.
+func scriptedServer(t *testing.T, responses ...func(w http.ResponseWriter)) (*Client, *[]time.Duration, *int) {
+	t.Helper()
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := min(calls, len(responses)-1)
+		calls++
+		responses[i](w)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient(srv.URL, "token", srv.Client())
+	var slept []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+	return c, &slept, &calls
+}
+
+func respond(code int, header http.Header) func(http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(http.StatusText(code)))
+	}
+}
+
+func okResponse(w http.ResponseWriter) {
+	_, _ = w.Write([]byte(`{"data": {"viewer": {"login": "octocat"}}}`))
+}
+
+func TestDoGraphQLRetries(t *testing.T) {
+	c, slept, calls := scriptedServer(t,
+		respond(http.StatusBadGateway, nil),
+		respond(http.StatusTooManyRequests, http.Header{"Retry-After": {"3"}}),
+		okResponse,
+	)
+
+	data, err := c.doGraphQL(context.Background(), "query { viewer { login } }", nil)
+	if err != nil {
+		t.Fatalf("doGraphQL: %v", err)
+	}
+	if string(data) != `{"viewer": {"login": "octocat"}}` {
+		t.Errorf("data = %s", data)
+	}
+	if *calls != 3 {
+		t.Errorf("%d requests, want 3", *calls)
+	}
+	if len(*slept) != 2 {
+		t.Fatalf("slept %v, want two waits", *slept)
+	}
+	if d := (*slept)[0]; d < baseBackoff/2 || d > baseBackoff {
+		t.Errorf("first wait = %v, want a backoff within [%v, %v]", d, baseBackoff/2, baseBackoff)
+	}
+	if d := (*slept)[1]; d != 3*time.Second {
+		t.Errorf("second wait = %v, want Retry-After of 3s", d)
+	}
+}
+
+func TestDoGraphQLGivesUp(t *testing.T) {
+	c, slept, calls := scriptedServer(t, respond(http.StatusInternalServerError, nil))
+	c.maxRetries = 2
+
+	_, err := c.doGraphQL(context.Background(), "query { viewer { login } }", nil)
+	if err == nil {
+		t.Fatal("doGraphQL succeeded, want an error")
+	}
+	if *calls != 3 || len(*slept) != 2 {
+		t.Errorf("%d requests and %d waits, want 3 and 2", *calls, len(*slept))
+	}
+}
+
+func TestDoGraphQLNoRetry(t *testing.T) {
+	c, slept, calls := scriptedServer(t, respond(http.StatusUnauthorized, nil), okResponse)
+
+	if _, err := c.doGraphQL(context.Background(), "query { viewer { login } }", nil); err == nil {
+		t.Fatal("doGraphQL succeeded, want the 401")
+	}
+	if *calls != 1 || len(*slept) != 0 {
+		t.Errorf("%d requests and %d waits, want 1 and 0", *calls, len(*slept))
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	var slept []time.Duration
+	sleep := func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	l := newRateLimiter()
+	if err := l.wait(context.Background(), sleep); err != nil || len(slept) != 0 {
+		t.Fatalf("wait before any response slept %v (%v)", slept, err)
+	}
+	l.update(10, time.Now().Add(time.Minute))
+	if err := l.wait(context.Background(), sleep); err != nil || len(slept) != 0 {
+		t.Fatalf("wait with budget left slept %v (%v)", slept, err)
+	}
+	l.update(0, time.Now().Add(time.Minute))
+	if err := l.wait(context.Background(), sleep); err != nil {
+		t.Fatal(err)
+	}
+	if len(slept) != 1 || slept[0] <= 50*time.Second || slept[0] > time.Minute {
+		t.Errorf("wait when exhausted slept %v, want about a minute", slept)
+	}
+}
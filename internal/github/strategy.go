@@ -3,6 +3,8 @@ package github
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/kevinmichaelchen/star-watch/internal/models"
 )
@@ -35,7 +37,7 @@ func (ForwardStrategy) Fetch(ctx context.Context, c *Client, listID string, _ []
 		}
 
 		allRepos = append(allRepos, page.Repos...)
-		fmt.Printf("  Fetched %d/%d repos\n", len(allRepos), page.TotalCount)
+		fmt.Printf("  Fetched %d/%d repos (page size %d)\n", len(allRepos), page.TotalCount, page.PageSize)
 
 		if !page.PageInfo.HasNextPage {
 			break
@@ -54,9 +56,15 @@ func (ForwardStrategy) Fetch(ctx context.Context, c *Client, listID string, _ []
 // continues backward until it hits a known repo. New repos are appended to
 // the cached set.
 //
-// Falls back to ForwardStrategy if the cache is empty.
+// Because that ordering is undocumented, the result is verified before it is
+// trusted (see verifyIncremental). Falls back to ForwardStrategy, with a
+// warning explaining why, if the cache is empty or verification fails.
 type IncrementalStrategy struct{}
 
+// headCheckSize is how many of the oldest items are re-fetched to confirm
+// the list still starts where the cache does.
+const headCheckSize = 3
+
 func (IncrementalStrategy) Fetch(ctx context.Context, c *Client, listID string, cached []models.Repo) ([]models.Repo, error) {
 	if len(cached) == 0 {
 		fmt.Println("  No cache — falling back to full fetch")
@@ -65,26 +73,31 @@ func (IncrementalStrategy) Fetch(ctx context.Context, c *Client, listID string,
 
 	known := make(map[string]bool, len(cached))
 	for _, r := range cached {
-		known[r.FullName] = true
+		known[repoID(r)] = true
 	}
 
 	// Paginate backward (newest first). Collect pages of new repos,
 	// then reverse page order so the final slice is oldest-to-newest.
 	var pages [][]models.Repo
+	var boundary []models.Repo // the page where known repos were reached
 	var cursor *string
+	totalCount := -1
 
 	for {
 		page, err := c.FetchPageBackward(ctx, listID, cursor)
 		if err != nil {
 			return nil, err
 		}
+		if totalCount < 0 {
+			totalCount = page.TotalCount
+		}
 
 		// Within a backward page, items are still in connection order
 		// (oldest to newest). Split into new and known.
 		var newOnPage []models.Repo
 		hitKnown := false
 		for _, repo := range page.Repos {
-			if known[repo.FullName] {
+			if known[repoID(repo)] {
 				hitKnown = true
 			} else {
 				newOnPage = append(newOnPage, repo)
@@ -95,22 +108,185 @@ func (IncrementalStrategy) Fetch(ctx context.Context, c *Client, listID string,
 			pages = append(pages, newOnPage)
 		}
 
+		if hitKnown {
+			boundary = page.Repos
+		}
 		if hitKnown || !page.PageInfo.HasPreviousPage {
 			break
 		}
 		cursor = &page.PageInfo.StartCursor
 	}
 
-	if len(pages) == 0 {
-		return cached, nil
-	}
-
 	// Reverse page order: we fetched last→first, but want oldest→newest.
 	var newRepos []models.Repo
 	for i := len(pages) - 1; i >= 0; i-- {
 		newRepos = append(newRepos, pages[i]...)
 	}
 
+	reason, err := verifyIncremental(ctx, c, listID, cached, boundary, len(newRepos), totalCount)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		fmt.Printf("  WARN: incremental fetch not trusted: %s — falling back to full fetch\n", reason)
+		return ForwardStrategy{}.Fetch(ctx, c, listID, nil)
+	}
+	if len(newRepos) == 0 {
+		return cached, nil
+	}
+
 	fmt.Printf("  Found %d new repos\n", len(newRepos))
 	return append(cached, newRepos...), nil
 }
+
+// verifyIncremental checks that an incremental fetch is consistent with the
+// oldest-first assumption. It returns a non-empty reason if it isn't:
+//
+//   - totalCount must equal cached + new (otherwise repos were removed, or
+//     new repos were skipped because they aren't at the end);
+//   - on the boundary page, known repos must all precede new ones and match
+//     the tail of the cache in order;
+//   - the first headCheckSize items of the list must match the cache's head.
+func verifyIncremental(ctx context.Context, c *Client, listID string, cached, boundary []models.Repo, newCount, totalCount int) (string, error) {
+	if n := len(cached) + newCount; n != totalCount {
+		return fmt.Sprintf("list has %d repos but cache+new has %d (repos removed or list reordered)", totalCount, n), nil
+	}
+
+	if boundary != nil {
+		cachedPos := make(map[string]int, len(cached))
+		for i, r := range cached {
+			cachedPos[repoID(r)] = i
+		}
+		var knownOnPage []models.Repo
+		sawNew := false
+		for _, r := range boundary {
+			if _, ok := cachedPos[repoID(r)]; !ok {
+				sawNew = true
+				continue
+			}
+			if sawNew {
+				return fmt.Sprintf("known repo %s appears after a new repo (list reordered)", r.FullName), nil
+			}
+			knownOnPage = append(knownOnPage, r)
+		}
+		tail := cached[len(cached)-len(knownOnPage):]
+		for i, r := range knownOnPage {
+			if repoID(tail[i]) != repoID(r) {
+				return fmt.Sprintf("%s is at cache position %d, expected %s (list reordered)",
+					r.FullName, cachedPos[repoID(r)], tail[i].FullName), nil
+			}
+		}
+	}
+
+	head, err := c.FetchHead(ctx, listID, min(headCheckSize, len(cached)))
+	if err != nil {
+		return "", err
+	}
+	for i, r := range head.Repos {
+		if i >= len(cached) || repoID(cached[i]) != repoID(r) {
+			return fmt.Sprintf("list starts with %s at position %d, cache has %s (list reordered)",
+				r.FullName, i, cachedName(cached, i)), nil
+		}
+	}
+	return "", nil
+}
+
+// repoID is what fetched repos are matched to cached ones by: the node ID,
+// which survives renames and transfers, or the name if it isn't known.
+func repoID(r models.Repo) string {
+	if r.NodeID != "" {
+		return r.NodeID
+	}
+	return r.FullName
+}
+
+func cachedName(cached []models.Repo, i int) string {
+	if i < len(cached) {
+		return cached[i].FullName
+	}
+	return "nothing"
+}
+
+// StarredListID is a pseudo list ID that selects the viewer's full set of
+// stars (User.starredRepositories) instead of a UserList.
+const StarredListID = "starred"
+
+// StarredStrategy fetches the viewer's starred repos via
+// User.starredRepositories ordered by STARRED_AT descending. The listID
+// argument is ignored. Every returned repo has StarredAt set.
+//
+// Unlike IncrementalStrategy, this relies on a documented sort order: with a
+// cache, paging stops at the first repo starred at or before the newest
+// known timestamp. Without a cache (or if the cache has no timestamps) it
+// fetches everything. The result is ordered oldest-starred first to match
+// the other strategies. As with IncrementalStrategy, a totalCount mismatch
+// (repos were unstarred) triggers a full fetch.
+type StarredStrategy struct{}
+
+func (StarredStrategy) Fetch(ctx context.Context, c *Client, _ string, cached []models.Repo) ([]models.Repo, error) {
+	var newest time.Time
+	for _, r := range cached {
+		if r.StarredAt != nil && r.StarredAt.After(newest) {
+			newest = *r.StarredAt
+		}
+	}
+	incremental := !newest.IsZero()
+	if !incremental {
+		cached = nil
+	}
+
+	// Pages arrive newest first.
+	var fresh []models.Repo
+	var cursor *string
+	totalCount := -1
+
+	for {
+		page, err := c.FetchStarredPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		if totalCount < 0 {
+			totalCount = page.TotalCount
+		}
+
+		hitKnown := false
+		for _, repo := range page.Repos {
+			if incremental && !repo.StarredAt.After(newest) {
+				hitKnown = true
+				break
+			}
+			fresh = append(fresh, repo)
+		}
+		if !incremental {
+			fmt.Printf("  Fetched %d/%d repos (page size %d)\n", len(fresh), page.TotalCount, page.PageSize)
+		}
+
+		if hitKnown || !page.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &page.PageInfo.EndCursor
+	}
+
+	slices.Reverse(fresh)
+
+	if incremental && len(fresh) > 0 {
+		fmt.Printf("  Found %d new repos\n", len(fresh))
+
+		// A repo that was unstarred and starred again is already cached;
+		// keep only its new position.
+		restarred := make(map[string]bool, len(fresh))
+		for _, r := range fresh {
+			restarred[repoID(r)] = true
+		}
+		cached = slices.DeleteFunc(slices.Clone(cached), func(r models.Repo) bool {
+			return restarred[repoID(r)]
+		})
+	}
+
+	repos := append(cached, fresh...)
+	if incremental && len(repos) != totalCount {
+		fmt.Printf("  Viewer has %d stars but cache+new has %d — repos were unstarred, doing full fetch\n", totalCount, len(repos))
+		return StarredStrategy{}.Fetch(ctx, c, "", nil)
+	}
+	return repos, nil
+}
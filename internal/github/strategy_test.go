@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+// listServer serves the given repos, oldest first, as the head of a star
+// list. Names may carry a node ID as "owner/name@ID".
+func listServer(t *testing.T, names []string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		n := len(names)
+		if first, ok := req.Variables["first"].(float64); ok {
+			n = min(n, int(first))
+		}
+		var nodes []map[string]any
+		for _, name := range names[:n] {
+			name, id, _ := strings.Cut(name, "@")
+			owner, repo, _ := strings.Cut(name, "/")
+			nodes = append(nodes, map[string]any{
+				"id":    id,
+				"owner": map[string]any{"login": owner},
+				"name":  repo,
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"node": map[string]any{
+					"items": map[string]any{"totalCount": len(names), "nodes": nodes},
+				},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, "token", srv.Client())
+}
+
+// repos builds cached repos from names written as for listServer.
+func repos(names ...string) []models.Repo {
+	out := make([]models.Repo, len(names))
+	for i, name := range names {
+		name, id, _ := strings.Cut(name, "@")
+		out[i] = models.Repo{FullName: name, NodeID: id}
+	}
+	return out
+}
+
+func TestVerifyIncremental(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     []string
+		cached   []models.Repo
+		boundary []models.Repo
+		newCount int
+		want     string // substring of the reason; "" means consistent
+	}{
+		{
+			name:     "appended",
+			list:     []string{"a/1", "a/2", "a/3", "a/4"},
+			cached:   repos("a/1", "a/2", "a/3"),
+			boundary: repos("a/3", "a/4"),
+			newCount: 1,
+		},
+		{
+			name:   "nothing new",
+			list:   []string{"a/1", "a/2"},
+			cached: repos("a/1", "a/2"),
+		},
+		{
+			name:     "count mismatch",
+			list:     []string{"a/1", "a/3"},
+			cached:   repos("a/1", "a/2", "a/3"),
+			newCount: 0,
+			want:     "repos removed or list reordered",
+		},
+		{
+			name:     "known after new",
+			list:     []string{"a/1", "a/2", "a/3", "a/4"},
+			cached:   repos("a/1", "a/2", "a/3"),
+			boundary: repos("a/4", "a/3"),
+			newCount: 1,
+			want:     "appears after a new repo",
+		},
+		{
+			name:     "boundary out of order",
+			list:     []string{"a/1", "a/2", "a/3", "a/4"},
+			cached:   repos("a/1", "a/2", "a/3"),
+			boundary: repos("a/2", "a/4"),
+			newCount: 1,
+			want:     "expected a/3",
+		},
+		{
+			name:     "renamed on boundary",
+			list:     []string{"a/1@R1", "a/2@R2", "b/3@R3", "a/4@R4"},
+			cached:   repos("a/1@R1", "a/2@R2", "a/3@R3"),
+			boundary: repos("b/3@R3", "a/4@R4"),
+			newCount: 1,
+		},
+		{
+			name:     "renamed in head",
+			list:     []string{"b/1@R1", "a/2@R2", "a/3@R3"},
+			cached:   repos("a/1@R1", "a/2@R2", "a/3@R3"),
+			boundary: repos("a/2@R2", "a/3@R3"),
+		},
+		{
+			name:     "same name, different repo",
+			list:     []string{"a/1@R9", "a/2@R2", "a/3@R3"},
+			cached:   repos("a/1@R1", "a/2@R2", "a/3@R3"),
+			boundary: repos("a/2@R2", "a/3@R3"),
+			want:     "list starts with a/1",
+		},
+		{
+			name:     "head reordered",
+			list:     []string{"a/2", "a/1", "a/3", "a/4"},
+			cached:   repos("a/1", "a/2", "a/3"),
+			boundary: repos("a/3", "a/4"),
+			newCount: 1,
+			want:     "list starts with a/2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := listServer(t, tt.list)
+			got, err := verifyIncremental(context.Background(), c, "list", tt.cached, tt.boundary, tt.newCount, len(tt.list))
+			if err != nil {
+				t.Fatalf("verifyIncremental() error = %v", err)
+			}
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("verifyIncremental() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Package importer extracts GitHub repo references from awesome-style
+// markdown documents.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kevinmichaelchen/star-watch/internal/github"
+)
+
+// maxDocumentSize caps how much of a remote document is read.
+const maxDocumentSize = 10 << 20
+
+var repoLink = regexp.MustCompile(`https?://(?:www\.)?github\.com/([A-Za-z0-9][A-Za-z0-9-]*)/([A-Za-z0-9._-]+)`)
+
+// reservedOwners are github.com paths that look like owner/name links but
+// aren't repos.
+var reservedOwners = map[string]bool{
+	"about":       true,
+	"apps":        true,
+	"collections": true,
+	"enterprise":  true,
+	"features":    true,
+	"login":       true,
+	"marketplace": true,
+	"orgs":        true,
+	"settings":    true,
+	"site":        true,
+	"sponsors":    true,
+	"topics":      true,
+	"trending":    true,
+	"users":       true,
+}
+
+// Parse returns the distinct repos linked from markdown, in order of first
+// appearance. Deep links (issues, blobs, ...) count as links to their repo.
+func Parse(markdown string) []github.RepoRef {
+	seen := map[string]bool{}
+	var refs []github.RepoRef
+	for _, m := range repoLink.FindAllStringSubmatch(markdown, -1) {
+		owner := m[1]
+		name := strings.TrimSuffix(strings.TrimRight(m[2], "."), ".git")
+		if reservedOwners[strings.ToLower(owner)] || name == "" {
+			continue
+		}
+		key := strings.ToLower(owner + "/" + name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		refs = append(refs, github.RepoRef{Owner: owner, Name: name})
+	}
+	return refs
+}
+
+// Read returns the document at src, which is either a local path or an
+// http(s) URL. github.com blob URLs are fetched from
+// raw.githubusercontent.com so the markdown source is returned, not HTML.
+func Read(ctx context.Context, src string) (string, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", src, err)
+		}
+		return string(data), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL(src), nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching %s: %w", src, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s: HTTP %d", src, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", src, err)
+	}
+	return string(data), nil
+}
+
+// rawURL rewrites https://github.com/owner/repo/blob/ref/path to its
+// raw.githubusercontent.com equivalent. Other URLs are returned unchanged.
+func rawURL(src string) string {
+	u, err := url.Parse(src)
+	if err != nil || u.Host != "github.com" {
+		return src
+	}
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+	if len(parts) < 4 || parts[2] != "blob" {
+		return src
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", parts[0], parts[1], parts[3])
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kevinmichaelchen/star-watch/internal/github"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []github.RepoRef
+	}{
+		{
+			name:     "list items",
+			markdown: "- [Foo](https://github.com/acme/foo) - does foo.\n- [Bar](https://github.com/acme/bar.js) - does bar.",
+			want:     []github.RepoRef{{Owner: "acme", Name: "foo"}, {Owner: "acme", Name: "bar.js"}},
+		},
+		{
+			name:     "deep links count once",
+			markdown: "https://github.com/acme/foo/issues/1 and https://www.github.com/Acme/Foo/blob/main/README.md",
+			want:     []github.RepoRef{{Owner: "acme", Name: "foo"}},
+		},
+		{
+			name:     "git suffix and trailing period",
+			markdown: "Clone https://github.com/acme/foo.git. See http://github.com/acme/bar.",
+			want:     []github.RepoRef{{Owner: "acme", Name: "foo"}, {Owner: "acme", Name: "bar"}},
+		},
+		{
+			name:     "reserved paths",
+			markdown: "[topic](https://github.com/topics/go) [sponsor](https://github.com/sponsors/acme) https://github.com/acme/foo",
+			want:     []github.RepoRef{{Owner: "acme", Name: "foo"}},
+		},
+		{
+			name:     "other hosts and profiles",
+			markdown: "https://gitlab.com/acme/foo https://github.com/acme",
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.markdown); !slices.Equal(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawURL(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"https://github.com/acme/awesome/blob/main/README.md", "https://raw.githubusercontent.com/acme/awesome/main/README.md"},
+		{"https://github.com/acme/awesome", "https://github.com/acme/awesome"},
+		{"https://example.com/list.md", "https://example.com/list.md"},
+	}
+	for _, tt := range tests {
+		if got := rawURL(tt.src); got != tt.want {
+			t.Errorf("rawURL(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
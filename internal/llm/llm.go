@@ -2,10 +2,13 @@ package llm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
 
+	"github.com/kevinmichaelchen/star-watch/internal/cost"
 	"github.com/kevinmichaelchen/star-watch/internal/models"
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -13,6 +16,7 @@ import (
 type Client struct {
 	client *openai.Client
 	model  string
+	budget *cost.Budget
 }
 
 func NewClient(baseURL, apiKey, model string) *Client {
@@ -24,6 +28,10 @@ func NewClient(baseURL, apiKey, model string) *Client {
 	}
 }
 
+// PromptVersion identifies systemPrompt in stored input hashes. Bump it when
+// changing the prompt so existing summaries are regenerated.
+const PromptVersion = "1"
+
 const systemPrompt = `You are a technical analyst. Given a GitHub repository's name, description, and README excerpt, produce a JSON object with:
 
 1. "summary": A 2-3 sentence summary of what the repo does, its main use case, and why it's notable.
@@ -32,7 +40,40 @@ const systemPrompt = `You are a technical analyst. Given a GitHub repository's n
 
 Return ONLY valid JSON. No markdown, no code fences.`
 
-func (c *Client) Summarize(ctx context.Context, repo models.Repo) (*models.SummaryResult, error) {
+// SetBudget records the token usage of every completion in b.
+func (c *Client) SetBudget(b *cost.Budget) { c.budget = b }
+
+// record adds a completion's usage to the budget, estimating it from the
+// messages when the provider doesn't report any.
+func (c *Client) record(usage openai.Usage, content string, messages ...string) {
+	in, out := usage.PromptTokens, usage.CompletionTokens
+	if in == 0 && out == 0 {
+		in, out = cost.ChatTokens(c.model, messages...), cost.Count(c.model, content)
+	}
+	c.budget.Record(c.model, in, out)
+}
+
+// Model returns the chat model used for summaries.
+func (c *Client) Model() string { return c.model }
+
+// InputHash fingerprints everything that determines a repo's summary: the
+// prompt version, the model and the repo text sent to it.
+func (c *Client) InputHash(repo models.Repo) string {
+	h := sha256.New()
+	for _, part := range []string{PromptVersion, c.model, summaryInput(repo)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SummaryPrompt returns the system and user messages Summarize sends for
+// repo.
+func SummaryPrompt(repo models.Repo) (system, user string) {
+	return systemPrompt, summaryInput(repo)
+}
+
+func summaryInput(repo models.Repo) string {
 	var parts []string
 	parts = append(parts, fmt.Sprintf("Repository: %s", repo.FullName))
 	if repo.Description != nil {
@@ -41,7 +82,11 @@ func (c *Client) Summarize(ctx context.Context, repo models.Repo) (*models.Summa
 	if repo.ReadmeExcerpt != nil {
 		parts = append(parts, fmt.Sprintf("README excerpt:\n%s", *repo.ReadmeExcerpt))
 	}
-	userMsg := strings.Join(parts, "\n\n")
+	return strings.Join(parts, "\n\n")
+}
+
+func (c *Client) Summarize(ctx context.Context, repo models.Repo) (*models.SummaryResult, error) {
+	userMsg := summaryInput(repo)
 
 	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
 		Model: c.model,
@@ -58,10 +103,12 @@ func (c *Client) Summarize(ctx context.Context, repo models.Repo) (*models.Summa
 	}
 
 	if len(resp.Choices) == 0 {
+		c.record(resp.Usage, "", systemPrompt, userMsg)
 		return nil, fmt.Errorf("no choices returned for %s", repo.FullName)
 	}
 
 	content := resp.Choices[0].Message.Content
+	c.record(resp.Usage, content, systemPrompt, userMsg)
 	content = stripCodeFences(content)
 
 	var result models.SummaryResult
@@ -88,3 +135,40 @@ func stripCodeFences(s string) string {
 	}
 	return s
 }
+
+const releasePrompt = `You summarize software release notes. Given a repository name, release tag and release notes, reply with ONE plain-text line (max ~20 words) describing the most important changes. No markdown, no preamble.`
+
+// SummarizeRelease condenses a release's notes into a single line.
+func (c *Client) SummarizeRelease(ctx context.Context, rel models.Release) (string, error) {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("Repository: %s", rel.FullName))
+	parts = append(parts, fmt.Sprintf("Tag: %s", rel.Tag))
+	if rel.Name != nil && *rel.Name != "" && *rel.Name != rel.Tag {
+		parts = append(parts, fmt.Sprintf("Title: %s", *rel.Name))
+	}
+	if rel.Notes != nil {
+		parts = append(parts, fmt.Sprintf("Release notes:\n%s", *rel.Notes))
+	}
+	userMsg := strings.Join(parts, "\n\n")
+
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: releasePrompt},
+			{Role: openai.ChatMessageRoleUser, Content: userMsg},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("LLM call for %s@%s: %w", rel.FullName, rel.Tag, err)
+	}
+	if len(resp.Choices) == 0 {
+		c.record(resp.Usage, "", releasePrompt, userMsg)
+		return "", fmt.Errorf("no choices returned for %s@%s", rel.FullName, rel.Tag)
+	}
+	c.record(resp.Usage, resp.Choices[0].Message.Content, releasePrompt, userMsg)
+
+	// Keep only the first line in case the model ignores the instruction.
+	line, _, _ := strings.Cut(strings.TrimSpace(resp.Choices[0].Message.Content), "\n")
+	return strings.TrimSpace(line), nil
+}
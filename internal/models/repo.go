@@ -1,22 +1,58 @@
 package models
 
+import "time"
+
 type Repo struct {
-	Owner         string    `json:"owner"`
-	Name          string    `json:"name"`
-	FullName      string    `json:"full_name"`
-	Description   *string   `json:"description"`
-	URL           string    `json:"url"`
-	HomepageURL   *string   `json:"homepage_url"`
-	Stars         int       `json:"stars"`
-	Language      *string   `json:"language"`
-	Topics        []string  `json:"topics"`
-	ReadmeExcerpt *string   `json:"readme_excerpt"`
-	AISummary     *string   `json:"ai_summary"`
-	AICategories  []string  `json:"ai_categories"`
-	Embedding     []float32 `json:"embedding"`
+	NodeID          string     `json:"node_id,omitempty"`     // stable GraphQL ID; the record key
+	DatabaseID      int64      `json:"database_id,omitempty"` // stable REST ID
+	Aliases         []string   `json:"aliases,omitempty"`     // every full_name seen for this repo
+	Owner           string     `json:"owner"`
+	Name            string     `json:"name"`
+	FullName        string     `json:"full_name"`
+	Description     *string    `json:"description"`
+	URL             string     `json:"url"`
+	HomepageURL     *string    `json:"homepage_url"`
+	Host            string     `json:"host,omitempty"` // github.com or a GHE host
+	Stars           int        `json:"stars"`
+	Forks           int        `json:"forks"`
+	OpenIssues      int        `json:"open_issues"`
+	Archived        bool       `json:"archived"`
+	Fork            bool       `json:"fork"`
+	License         *string    `json:"license"`
+	CreatedAt       *time.Time `json:"created_at"`
+	PushedAt        *time.Time `json:"pushed_at"`
+	LatestRelease   *string    `json:"latest_release"`
+	LatestReleaseAt *time.Time `json:"latest_release_at"`
+	Language        *string    `json:"language"`
+	Topics          []string   `json:"topics"`
+	ReadmeExcerpt   *string    `json:"readme_excerpt"`
+	ReadmePath      *string    `json:"readme_path,omitempty"`
+	ReadmeLength    *int       `json:"readme_length,omitempty"` // raw bytes, before cleaning
+	AISummary       *string    `json:"ai_summary"`
+	AICategories    []string   `json:"ai_categories"`
+	EnrichHash      *string    `json:"enrich_hash,omitempty"`   // llm input hash of the stored summary
+	EnrichModel     *string    `json:"enrich_model,omitempty"`  // model that wrote the summary
+	EnrichPrompt    *string    `json:"enrich_prompt,omitempty"` // prompt version of the summary
+	Embedding       []float32  `json:"embedding"`
+	StarredAt       *time.Time `json:"starred_at,omitempty"`
+	FetchedAt       *time.Time `json:"fetched_at,omitempty"`
+	ImportSource    []string   `json:"import_source,omitempty"` // files, URLs or labels the repo was imported from
 }
 
 type SummaryResult struct {
 	Summary    string   `json:"summary"`
 	Categories []string `json:"categories"`
 }
+
+// Release is a published GitHub release of a repo.
+type Release struct {
+	RepoKey     string     `json:"repo_key,omitempty"`
+	FullName    string     `json:"full_name,omitempty"`
+	Tag         string     `json:"tag"`
+	Name        *string    `json:"name"`
+	URL         string     `json:"url"`
+	PublishedAt *time.Time `json:"published_at"`
+	Prerelease  bool       `json:"prerelease"`
+	Notes       *string    `json:"notes"`
+	AISummary   *string    `json:"ai_summary"`
+}
@@ -0,0 +1,277 @@
+// Package organize files repos into GitHub star lists named after their AI
+// categories.
+package organize
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kevinmichaelchen/star-watch/internal/github"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+)
+
+// skippedCategories don't get a list of their own.
+var skippedCategories = map[string]bool{
+	"Other": true,
+}
+
+// Add is one repo to add to the list for a category.
+type Add struct {
+	Repo     models.Repo
+	Category string
+	ListID   string // empty until the list is created
+}
+
+// Plan is the diff between repo categories and the viewer's star lists.
+type Plan struct {
+	Create  []string // categories without a list yet
+	Adds    []Add
+	Present int // repos already in their category list
+	Skipped int // repos once in their category list but since removed
+}
+
+// Lists maps each category to an existing list whose name or slug matches
+// it, ignoring case.
+func Lists(categories []string, lists []github.List) map[string]github.List {
+	out := map[string]github.List{}
+	for _, cat := range categories {
+		for _, l := range lists {
+			if strings.EqualFold(l.Name, cat) || strings.EqualFold(l.Slug, Slug(cat)) {
+				out[cat] = l
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Slug approximates the slug GitHub derives from a list name.
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// Categories returns the distinct categories of repos, sorted, minus those
+// that are skipped.
+func Categories(repos []models.Repo) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, r := range repos {
+		for _, cat := range r.AICategories {
+			if !seen[cat] && !skippedCategories[cat] {
+				seen[cat] = true
+				out = append(out, cat)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Build computes the plan. lists maps categories to existing lists (see
+// Lists), members holds the repo node IDs currently in each list, and
+// assigned holds "nodeID|listID" pairs that have been recorded before: a
+// recorded pair that is no longer a member was removed by hand and is not
+// re-added.
+func Build(repos []models.Repo, lists map[string]github.List, members map[string]map[string]bool, assigned map[string]bool) Plan {
+	var p Plan
+	for _, cat := range Categories(repos) {
+		if _, ok := lists[cat]; !ok {
+			p.Create = append(p.Create, cat)
+		}
+	}
+
+	for _, r := range repos {
+		for _, cat := range r.AICategories {
+			if skippedCategories[cat] {
+				continue
+			}
+			l, ok := lists[cat]
+			if !ok {
+				p.Adds = append(p.Adds, Add{Repo: r, Category: cat})
+				continue
+			}
+			switch {
+			case members[l.ID][r.NodeID]:
+				p.Present++
+			case assigned[Pair(r.NodeID, l.ID)]:
+				p.Skipped++
+			default:
+				p.Adds = append(p.Adds, Add{Repo: r, Category: cat, ListID: l.ID})
+			}
+		}
+	}
+	return p
+}
+
+// Observed returns the members of the category lists that have no recorded
+// assignment yet, as assignments with origin "observed".
+func Observed(lists map[string]github.List, members map[string]map[string]bool, assigned map[string]bool) []surrealdb.ListAssignment {
+	var out []surrealdb.ListAssignment
+	for _, l := range lists {
+		for id := range members[l.ID] {
+			if !assigned[Pair(id, l.ID)] {
+				out = append(out, surrealdb.ListAssignment{NodeID: id, ListID: l.ID, Origin: "observed"})
+			}
+		}
+	}
+	return out
+}
+
+// Pair is the key of a (repo, list) assignment.
+func Pair(nodeID, listID string) string {
+	return nodeID + "|" + listID
+}
+
+// Options controls Run.
+type Options struct {
+	DryRun bool // print the plan without touching GitHub
+	Public bool // create missing lists as public; they are private otherwise
+}
+
+// Run plans and applies the filing of categorized repos into the viewer's
+// star lists.
+func Run(ctx context.Context, gh *github.Client, db *surrealdb.Client, opts Options) error {
+	repos, err := db.GetCategorizedRepos(ctx)
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		fmt.Println("No categorized repos (run `star-watch sync` first)")
+		return nil
+	}
+
+	lists, err := gh.FetchLists(ctx)
+	if err != nil {
+		return err
+	}
+	// updateUserListsForItem replaces a repo's memberships, so we need
+	// every list's items, not just the category lists.
+	members := make(map[string]map[string]bool, len(lists))
+	for _, l := range lists {
+		ids, err := gh.FetchListItemIDs(ctx, l.ID)
+		if err != nil {
+			return err
+		}
+		members[l.ID] = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			members[l.ID][id] = true
+		}
+	}
+
+	recorded, err := db.GetListAssignments(ctx)
+	if err != nil {
+		return err
+	}
+	assigned := make(map[string]bool, len(recorded))
+	for _, a := range recorded {
+		assigned[Pair(a.NodeID, a.ListID)] = true
+	}
+
+	catLists := Lists(Categories(repos), lists)
+	plan := Build(repos, catLists, members, assigned)
+	Print(plan)
+	if opts.DryRun {
+		return nil
+	}
+
+	// Remember current members of the category lists even when there is
+	// nothing to add, so repos removed from them later are left alone.
+	if err := db.RecordListAssignments(ctx, Observed(catLists, members, assigned)); err != nil {
+		return err
+	}
+	if len(plan.Create) == 0 && len(plan.Adds) == 0 {
+		return nil
+	}
+
+	return apply(ctx, gh, db, plan, members, !opts.Public)
+}
+
+// Print writes the plan grouped by category.
+func Print(plan Plan) {
+	for _, cat := range plan.Create {
+		fmt.Printf("Create list %q\n", cat)
+	}
+	byCat := map[string][]string{}
+	var cats []string
+	for _, a := range plan.Adds {
+		if _, ok := byCat[a.Category]; !ok {
+			cats = append(cats, a.Category)
+		}
+		byCat[a.Category] = append(byCat[a.Category], a.Repo.FullName)
+	}
+	sort.Strings(cats)
+	for _, cat := range cats {
+		fmt.Printf("\n%s (+%d):\n", cat, len(byCat[cat]))
+		for _, name := range byCat[cat] {
+			fmt.Printf("  + %s\n", name)
+		}
+	}
+	fmt.Printf("\n%d to add, %d already listed, %d removed by hand (not re-added)\n",
+		len(plan.Adds), plan.Present, plan.Skipped)
+}
+
+// apply creates missing lists and adds repos to their category lists,
+// recording each addition. Failures for one repo are reported and skipped.
+func apply(ctx context.Context, gh *github.Client, db *surrealdb.Client, plan Plan, members map[string]map[string]bool, private bool) error {
+	created := map[string]string{}
+	for _, cat := range plan.Create {
+		l, err := gh.CreateList(ctx, cat, fmt.Sprintf("Repos categorized as %s by star-watch", cat), private)
+		if err != nil {
+			return err
+		}
+		created[cat] = l.ID
+		fmt.Printf("Created list %q (%s)\n", l.Name, l.ID)
+	}
+
+	// One mutation per repo, covering all of its new lists.
+	adds := map[string][]string{}
+	var order []models.Repo
+	for _, a := range plan.Adds {
+		listID := a.ListID
+		if listID == "" {
+			listID = created[a.Category]
+		}
+		if _, ok := adds[a.Repo.NodeID]; !ok {
+			order = append(order, a.Repo)
+		}
+		adds[a.Repo.NodeID] = append(adds[a.Repo.NodeID], listID)
+	}
+
+	done := 0
+	for _, r := range order {
+		listIDs := adds[r.NodeID]
+		for listID, ids := range members {
+			if ids[r.NodeID] {
+				listIDs = append(listIDs, listID)
+			}
+		}
+		if err := gh.SetListsForItem(ctx, r.NodeID, listIDs); err != nil {
+			fmt.Printf("  WARN: %s: %v\n", r.FullName, err)
+			continue
+		}
+		var recorded []surrealdb.ListAssignment
+		for _, listID := range adds[r.NodeID] {
+			recorded = append(recorded, surrealdb.ListAssignment{NodeID: r.NodeID, ListID: listID, Origin: "organize"})
+		}
+		if err := db.RecordListAssignments(ctx, recorded); err != nil {
+			return err
+		}
+		done++
+	}
+	fmt.Printf("Added %d repos to star lists\n", done)
+	return nil
+}
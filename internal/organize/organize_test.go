@@ -0,0 +1,84 @@
+package organize
+
+import (
+	"testing"
+
+	"github.com/kevinmichaelchen/star-watch/internal/github"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	repos := []models.Repo{
+		{NodeID: "R1", FullName: "acme/agent", AICategories: []string{"AI Agent"}},
+		{NodeID: "R2", FullName: "acme/rag", AICategories: []string{"RAG", "Other"}},
+	}
+	lists := map[string]github.List{"AI Agent": {ID: "L1", Name: "AI Agent"}}
+	tests := []struct {
+		name        string
+		members     map[string]map[string]bool
+		assigned    map[string]bool
+		wantCreate  int
+		wantAdds    int
+		wantPresent int
+		wantSkipped int
+	}{
+		{
+			name:       "new list and new member",
+			wantCreate: 1,
+			wantAdds:   2,
+		},
+		{
+			name:        "already listed",
+			members:     map[string]map[string]bool{"L1": {"R1": true}},
+			wantCreate:  1,
+			wantAdds:    1,
+			wantPresent: 1,
+		},
+		{
+			name:        "removed by hand",
+			assigned:    map[string]bool{Pair("R1", "L1"): true},
+			wantCreate:  1,
+			wantAdds:    1,
+			wantSkipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Build(repos, lists, tt.members, tt.assigned)
+			if len(p.Create) != tt.wantCreate || len(p.Adds) != tt.wantAdds || p.Present != tt.wantPresent || p.Skipped != tt.wantSkipped {
+				t.Errorf("Build() = %d create, %d adds, %d present, %d skipped, want %d, %d, %d, %d",
+					len(p.Create), len(p.Adds), p.Present, p.Skipped,
+					tt.wantCreate, tt.wantAdds, tt.wantPresent, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// A repo the user filed by hand is observed on a run with nothing to add;
+// once they remove it from the list, the next run leaves it out.
+func TestManualRemovalNotUndone(t *testing.T) {
+	repos := []models.Repo{{NodeID: "R1", FullName: "acme/agent", AICategories: []string{"AI Agent"}}}
+	lists := map[string]github.List{"AI Agent": {ID: "L1", Name: "AI Agent"}}
+	assigned := map[string]bool{}
+
+	members := map[string]map[string]bool{"L1": {"R1": true}}
+	if p := Build(repos, lists, members, assigned); len(p.Create) != 0 || len(p.Adds) != 0 {
+		t.Fatalf("first run plan = %+v, want nothing to do", p)
+	}
+	observed := Observed(lists, members, assigned)
+	if len(observed) != 1 || observed[0].NodeID != "R1" || observed[0].ListID != "L1" || observed[0].Origin != "observed" {
+		t.Fatalf("Observed() = %+v, want R1 in L1", observed)
+	}
+	for _, a := range observed {
+		assigned[Pair(a.NodeID, a.ListID)] = true
+	}
+
+	members = map[string]map[string]bool{"L1": {}}
+	p := Build(repos, lists, members, assigned)
+	if len(p.Adds) != 0 || p.Skipped != 1 {
+		t.Errorf("after removal plan = %d adds, %d skipped, want 0, 1", len(p.Adds), p.Skipped)
+	}
+	if got := Observed(lists, members, assigned); len(got) != 0 {
+		t.Errorf("Observed() after removal = %+v, want none", got)
+	}
+}
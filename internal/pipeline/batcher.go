@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+const (
+	// streamBatchSize and streamFlushAfter bound how long a freshly
+	// enriched repo waits for its embedding: a batch is sent once it is
+	// full or its oldest repo has waited this long.
+	streamBatchSize  = 64
+	streamFlushAfter = 5 * time.Second
+)
+
+// embedBatcher embeds repos as enrichment produces them. Add queues a repo;
+// a consumer goroutine flushes batches through embed, so embedding overlaps
+// with summarization instead of waiting for it to finish.
+type embedBatcher struct {
+	in         chan models.Repo
+	done       chan struct{}
+	flushAfter time.Duration
+	embed      func(ctx context.Context, repos []models.Repo) (int, error)
+
+	// Owned by the consumer until done is closed.
+	stored int
+	err    error
+}
+
+// startEmbedBatcher starts the consumer. A partial batch is flushed once
+// its oldest repo has waited flushAfter (streamFlushAfter outside tests).
+func startEmbedBatcher(ctx context.Context, flushAfter time.Duration, embed func(context.Context, []models.Repo) (int, error)) *embedBatcher {
+	b := &embedBatcher{
+		in:         make(chan models.Repo, streamBatchSize),
+		done:       make(chan struct{}),
+		flushAfter: flushAfter,
+		embed:      embed,
+	}
+	go b.run(ctx)
+	return b
+}
+
+// Add queues an enriched repo. It is safe for concurrent use, and blocks
+// only while a full queue waits on a flush.
+func (b *embedBatcher) Add(r models.Repo) {
+	b.in <- r
+}
+
+// Close flushes queued repos, waits for the consumer and returns how many
+// embeddings were stored. The first embed error, if any, is returned; later
+// batches are dropped after it.
+func (b *embedBatcher) Close() (int, error) {
+	close(b.in)
+	<-b.done
+	return b.stored, b.err
+}
+
+func (b *embedBatcher) run(ctx context.Context) {
+	defer close(b.done)
+
+	var batch []models.Repo
+	timer := time.NewTimer(b.flushAfter)
+	timer.Stop()
+	flush := func() {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		if b.err == nil {
+			n, err := b.embed(ctx, batch)
+			b.stored += n
+			b.err = err
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case r, ok := <-b.in:
+			if !ok {
+				flush()
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(b.flushAfter)
+			}
+			batch = append(batch, r)
+			if len(batch) >= streamBatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
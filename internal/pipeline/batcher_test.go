@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+// recorder is an embed callback that records the batches it is given and
+// reports each one on flushed.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]string
+	flushed chan int
+	fail    error // returned for every batch if set
+}
+
+func newRecorder() *recorder {
+	return &recorder{flushed: make(chan int, 100)}
+}
+
+func (r *recorder) embed(_ context.Context, repos []models.Repo) (int, error) {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.FullName
+	}
+	r.mu.Lock()
+	r.batches = append(r.batches, names)
+	r.mu.Unlock()
+	r.flushed <- len(repos)
+	if r.fail != nil {
+		return 1, r.fail
+	}
+	return len(repos), nil
+}
+
+func (r *recorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]int, len(r.batches))
+	for i, b := range r.batches {
+		out[i] = len(b)
+	}
+	return out
+}
+
+func addRepos(b *embedBatcher, from, n int) {
+	for i := from; i < from+n; i++ {
+		b.Add(models.Repo{FullName: fmt.Sprintf("o/r%d", i)})
+	}
+}
+
+// waitFlush waits for the next batch to reach the recorder.
+func waitFlush(t *testing.T, r *recorder) int {
+	t.Helper()
+	select {
+	case n := <-r.flushed:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch flushed")
+		return 0
+	}
+}
+
+func TestEmbedBatcherFlushesFullBatch(t *testing.T) {
+	r := newRecorder()
+	b := startEmbedBatcher(context.Background(), time.Hour, r.embed)
+
+	addRepos(b, 0, streamBatchSize+10)
+	if n := waitFlush(t, r); n != streamBatchSize {
+		t.Errorf("first batch has %d repos, want %d", n, streamBatchSize)
+	}
+
+	stored, err := b.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if stored != streamBatchSize+10 {
+		t.Errorf("stored = %d, want %d", stored, streamBatchSize+10)
+	}
+	if got := r.sizes(); !slices.Equal(got, []int{streamBatchSize, 10}) {
+		t.Errorf("batch sizes %v, want [%d 10]", got, streamBatchSize)
+	}
+}
+
+func TestEmbedBatcherFlushesAfterInterval(t *testing.T) {
+	r := newRecorder()
+	b := startEmbedBatcher(context.Background(), 10*time.Millisecond, r.embed)
+
+	addRepos(b, 0, 3)
+	if n := waitFlush(t, r); n != 3 {
+		t.Errorf("timed flush sent %d repos, want 3", n)
+	}
+	// The timer restarts with the next repo rather than firing again on an
+	// empty batch.
+	addRepos(b, 3, 2)
+	if n := waitFlush(t, r); n != 2 {
+		t.Errorf("second timed flush sent %d repos, want 2", n)
+	}
+
+	if stored, err := b.Close(); stored != 5 || err != nil {
+		t.Errorf("Close = %d, %v; want 5, nil", stored, err)
+	}
+	if got := r.sizes(); !slices.Equal(got, []int{3, 2}) {
+		t.Errorf("batch sizes %v, want [3 2]", got)
+	}
+}
+
+func TestEmbedBatcherDrainsOnClose(t *testing.T) {
+	r := newRecorder()
+	b := startEmbedBatcher(context.Background(), time.Hour, r.embed)
+
+	addRepos(b, 0, 5)
+	stored, err := b.Close()
+	if err != nil || stored != 5 {
+		t.Fatalf("Close = %d, %v; want 5, nil", stored, err)
+	}
+	if got := r.sizes(); !slices.Equal(got, []int{5}) {
+		t.Errorf("batch sizes %v, want [5]", got)
+	}
+}
+
+func TestEmbedBatcherCloseEmpty(t *testing.T) {
+	r := newRecorder()
+	b := startEmbedBatcher(context.Background(), time.Hour, r.embed)
+
+	if stored, err := b.Close(); stored != 0 || err != nil {
+		t.Errorf("Close = %d, %v; want 0, nil", stored, err)
+	}
+	if got := r.sizes(); len(got) != 0 {
+		t.Errorf("embedded %v, want nothing", got)
+	}
+}
+
+func TestEmbedBatcherStopsAfterError(t *testing.T) {
+	r := newRecorder()
+	r.fail = errors.New("endpoint down")
+	b := startEmbedBatcher(context.Background(), time.Hour, r.embed)
+
+	addRepos(b, 0, 2*streamBatchSize+1)
+	stored, err := b.Close()
+	if !errors.Is(err, r.fail) {
+		t.Fatalf("Close error = %v, want %v", err, r.fail)
+	}
+	if stored != 1 {
+		t.Errorf("stored = %d, want what the failing batch stored (1)", stored)
+	}
+	if got := r.sizes(); !slices.Equal(got, []int{streamBatchSize}) {
+		t.Errorf("batch sizes %v, want only the failing batch", got)
+	}
+}
+
+func TestEmbedBatcherConcurrentAdd(t *testing.T) {
+	r := newRecorder()
+	r.flushed = make(chan int, 1000)
+	b := startEmbedBatcher(context.Background(), time.Millisecond, r.embed)
+
+	const workers, perWorker = 8, 100
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addRepos(b, w*perWorker, perWorker)
+		}()
+	}
+	wg.Wait()
+
+	stored, err := b.Close()
+	if err != nil || stored != workers*perWorker {
+		t.Fatalf("Close = %d, %v; want %d, nil", stored, err, workers*perWorker)
+	}
+	seen := map[string]bool{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, batch := range r.batches {
+		if len(batch) > streamBatchSize {
+			t.Errorf("batch of %d repos, want at most %d", len(batch), streamBatchSize)
+		}
+		for _, name := range batch {
+			if seen[name] {
+				t.Errorf("%s embedded twice", name)
+			}
+			seen[name] = true
+		}
+	}
+	if len(seen) != workers*perWorker {
+		t.Errorf("embedded %d distinct repos, want %d", len(seen), workers*perWorker)
+	}
+}
@@ -0,0 +1,306 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kevinmichaelchen/star-watch/internal/cost"
+	"github.com/kevinmichaelchen/star-watch/internal/llm"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+)
+
+// defaultSummaryTokens is the assumed size of an LLM response when there
+// are no stored summaries to average.
+const defaultSummaryTokens = 100
+
+// estimate is what a dry run would send to the LLM and embedding APIs.
+type estimate struct {
+	known    map[string]models.Repo // live repos in SurrealDB, by key
+	enriched map[string]bool        // keys of repos enrich would summarize
+
+	summaryTokens int // expected response tokens per summary
+
+	enrichRepos, enrichIn, enrichOut int
+	embedRepos, embedIn              int
+}
+
+// dryRun runs each planned stage's estimate and prints the projected token
+// usage and cost. Nothing is sent to the LLM or embedding APIs and nothing
+// is written to SurrealDB.
+func dryRun(ctx context.Context, s *syncState, plan []*stage) error {
+	prices, err := cost.LoadPrices(s.cfg.ModelPrices)
+	if err != nil {
+		return fmt.Errorf("MODEL_PRICES: %w", err)
+	}
+
+	fmt.Println("Dry run: no LLM or embedding calls, no database or cache writes")
+	s.est = &estimate{}
+	for _, st := range plan {
+		if err := st.estimate(ctx, s); err != nil {
+			return fmt.Errorf("%s: %w", st.name, err)
+		}
+	}
+
+	e := s.est
+	fmt.Println()
+	fmt.Println("Estimated usage (output tokens assume summaries of average length):")
+	var total float64
+	var unpriced []string
+	line := func(label, model string, repos, in, out int) {
+		usd, ok := prices.Cost(model, in, out)
+		price := "no price (set MODEL_PRICES)"
+		if ok {
+			price = cost.Format(usd)
+			total += usd
+		} else {
+			unpriced = append(unpriced, model)
+		}
+		tokens := fmt.Sprintf("%d input", in)
+		if out > 0 {
+			tokens += fmt.Sprintf(" + ~%d output", out)
+		}
+		fmt.Printf("  %s: %d repos, %s tokens with %s: ~%s\n", label, repos, tokens, model, price)
+		if encoding, exact := cost.Encoding(model); !exact {
+			fmt.Printf("    no bundled tokenizer for %s; counted with %s, which may be off by several percent\n", model, encoding)
+		}
+	}
+	if s.planned[StageEnrich] {
+		line("Enrich", s.cfg.LLMModel, e.enrichRepos, e.enrichIn, e.enrichOut)
+	}
+	if s.planned[StageEmbed] {
+		line("Embed", s.cfg.EmbeddingModel, e.embedRepos, e.embedIn, 0)
+	}
+	if len(unpriced) == 0 {
+		fmt.Printf("  Total: ~%s\n", cost.Format(total))
+	} else {
+		fmt.Printf("  Total: at least ~%s, not counting %s (no price; set MODEL_PRICES)\n", cost.Format(total), strings.Join(unpriced, " and "))
+	}
+	return nil
+}
+
+// knownRepos loads the live repos in SurrealDB once per dry run.
+func knownRepos(ctx context.Context, s *syncState) (map[string]models.Repo, error) {
+	if s.est.known != nil {
+		return s.est.known, nil
+	}
+	repos, err := s.db.GetEnrichmentCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.est.known = make(map[string]models.Repo, len(repos))
+	for _, r := range repos {
+		s.est.known[surrealdb.RepoKey(r)] = r
+	}
+	return s.est.known, nil
+}
+
+// withFetched returns repos as they'll look once this run's upsert is
+// done: stored repos take the fetched metadata and keep their enrichment,
+// and fetched repos that aren't stored yet are added. Without an upsert in
+// the plan, repos is returned unchanged.
+func withFetched(ctx context.Context, s *syncState, repos []models.Repo) ([]models.Repo, error) {
+	if !s.planned[StageUpsert] {
+		return repos, nil
+	}
+	known, err := knownRepos(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	fetched := make(map[string]models.Repo, len(s.repos))
+	for _, r := range s.repos {
+		fetched[surrealdb.RepoKey(r)] = r
+	}
+
+	out := make([]models.Repo, 0, len(repos))
+	for _, r := range repos {
+		if f, ok := fetched[surrealdb.RepoKey(r)]; ok {
+			f.AISummary, f.AICategories, f.Embedding = r.AISummary, r.AICategories, r.Embedding
+			f.EnrichHash, f.EnrichModel, f.EnrichPrompt = r.EnrichHash, r.EnrichModel, r.EnrichPrompt
+			r = f
+		}
+		out = append(out, r)
+	}
+	for _, r := range s.repos {
+		if _, ok := known[surrealdb.RepoKey(r)]; !ok {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func estimateUpsert(ctx context.Context, s *syncState) error {
+	known, err := knownRepos(ctx, s)
+	if err != nil {
+		return err
+	}
+	added := 0
+	for _, r := range s.repos {
+		if _, ok := known[surrealdb.RepoKey(r)]; !ok {
+			added++
+		}
+	}
+	fmt.Printf("Would upsert %d repos (%d new)\n", len(s.repos), added)
+	return nil
+}
+
+func estimateReleases(ctx context.Context, s *syncState) error {
+	fmt.Printf("Would fetch releases for %d repos from GitHub\n", len(s.repos))
+	return nil
+}
+
+// estimateEnrich picks repos the way enrichRepos does and counts the
+// prompt tokens it would send. Response tokens are assumed to match the
+// average stored summary.
+func estimateEnrich(ctx context.Context, s *syncState) error {
+	llmClient := llm.NewClient(s.cfg.LLMBaseURL, s.cfg.LLMAPIKey, s.cfg.LLMModel)
+
+	known, err := knownRepos(ctx, s)
+	if err != nil {
+		return err
+	}
+	var candidates []models.Repo
+	switch {
+	case s.opts.Force && s.runID != "":
+		candidates, err = s.db.GetReposToReenrich(ctx, s.runID)
+	default:
+		for _, r := range known {
+			candidates = append(candidates, r)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if candidates, err = withFetched(ctx, s, candidates); err != nil {
+		return err
+	}
+
+	toEnrich := candidates
+	if !s.opts.Force {
+		var baseline []models.Repo
+		toEnrich, baseline = selectStale(candidates, llmClient)
+		if len(baseline) > 0 {
+			fmt.Printf("Would record input hashes for %d previously enriched repos\n", len(baseline))
+		}
+	}
+
+	e := s.est
+	e.summaryTokens = averageSummaryTokens(s.cfg.LLMModel, known)
+	e.enriched = make(map[string]bool, len(toEnrich))
+	for _, r := range toEnrich {
+		e.enriched[surrealdb.RepoKey(r)] = true
+		system, user := llm.SummaryPrompt(r)
+		e.enrichIn += cost.ChatTokens(s.cfg.LLMModel, system, user)
+	}
+	e.enrichRepos = len(toEnrich)
+	e.enrichOut = len(toEnrich) * e.summaryTokens
+
+	printRepos("enrich", toEnrich)
+	return nil
+}
+
+// estimateEmbed picks repos the way embedRepos does, plus every repo enrich
+// would summarize, and counts the tokens of their embedding text. Summaries
+// that don't exist yet are assumed to be of average length.
+func estimateEmbed(ctx context.Context, s *syncState) error {
+	var (
+		toEmbed []models.Repo
+		err     error
+	)
+	switch {
+	case s.opts.Force && s.runID != "":
+		toEmbed, err = s.db.GetReposToReembed(ctx, s.runID)
+	default:
+		toEmbed, err = s.db.GetReposNeedingEmbedding(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if toEmbed, err = withFetched(ctx, s, toEmbed); err != nil {
+		return err
+	}
+
+	e := s.est
+	if e.summaryTokens == 0 {
+		known, err := knownRepos(ctx, s)
+		if err != nil {
+			return err
+		}
+		e.summaryTokens = averageSummaryTokens(s.cfg.LLMModel, known)
+	}
+	var names []models.Repo
+	for _, r := range toEmbed {
+		if e.enriched[surrealdb.RepoKey(r)] {
+			continue // counted below with an estimated summary
+		}
+		if r.AISummary == nil && !s.opts.Force {
+			continue // not enriched yet; embedRepos won't pick it up
+		}
+		names = append(names, r)
+		e.embedIn += cost.Count(s.cfg.EmbeddingModel, embeddingText(r))
+	}
+	if s.planned[StageEnrich] {
+		for _, r := range candidatesByKey(s, e.enriched) {
+			names = append(names, r)
+			e.embedIn += cost.Count(s.cfg.EmbeddingModel, r.FullName+": ") + e.summaryTokens
+		}
+	}
+	e.embedRepos = len(names)
+
+	printRepos("embed", names)
+	return nil
+}
+
+// candidatesByKey returns the repos with the given keys, preferring the
+// fetched version of each.
+func candidatesByKey(s *syncState, keys map[string]bool) []models.Repo {
+	var out []models.Repo
+	seen := map[string]bool{}
+	for _, r := range s.repos {
+		if key := surrealdb.RepoKey(r); keys[key] && !seen[key] {
+			seen[key] = true
+			out = append(out, r)
+		}
+	}
+	for key, r := range s.est.known {
+		if keys[key] && !seen[key] {
+			seen[key] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// averageSummaryTokens is the mean size of stored LLM responses, rebuilt
+// from each summary and its categories.
+func averageSummaryTokens(model string, repos map[string]models.Repo) int {
+	total, n := 0, 0
+	for _, r := range repos {
+		if r.AISummary == nil {
+			continue
+		}
+		resp, err := json.Marshal(models.SummaryResult{Summary: *r.AISummary, Categories: r.AICategories})
+		if err != nil {
+			continue
+		}
+		total += cost.Count(model, string(resp))
+		n++
+	}
+	if n == 0 {
+		return defaultSummaryTokens
+	}
+	return total / n
+}
+
+// printRepos lists the repos a stage would process, by name.
+func printRepos(verb string, repos []models.Repo) {
+	fmt.Printf("Would %s %d repos\n", verb, len(repos))
+	sorted := slices.Clone(repos)
+	slices.SortFunc(sorted, func(a, b models.Repo) int { return strings.Compare(a.FullName, b.FullName) })
+	for _, r := range sorted {
+		fmt.Printf("  %s\n", r.FullName)
+	}
+}
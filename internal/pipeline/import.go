@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kevinmichaelchen/star-watch/internal/config"
+	"github.com/kevinmichaelchen/star-watch/internal/importer"
+	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+)
+
+type ImportOptions struct {
+	Label      string // added to import_source; defaults to the file path or URL
+	SkipEnrich bool
+}
+
+// Import upserts every github.com repo linked from a markdown file or URL,
+// tagged with import_source, then enriches and embeds them like starred
+// repos. Only the imported repos are enriched.
+func Import(ctx context.Context, cfg *config.Config, src string, opts ImportOptions) error {
+	label := opts.Label
+	if label == "" {
+		label = src
+	}
+
+	doc, err := importer.Read(ctx, src)
+	if err != nil {
+		return err
+	}
+	refs := importer.Parse(doc)
+	if len(refs) == 0 {
+		fmt.Printf("No GitHub repo links found in %s\n", src)
+		return nil
+	}
+	fmt.Printf("Found %d repo links in %s\n", len(refs), src)
+
+	ghs := &githubClients{cfg: cfg}
+	gh, err := ghs.get("")
+	if err != nil {
+		return err
+	}
+	fmt.Println("Fetching repo metadata from GitHub...")
+	repos, err := gh.FetchRepos(ctx, refs)
+	if err != nil {
+		return err
+	}
+
+	for i := range repos {
+		repos[i].ImportSource = []string{label}
+	}
+	if missing := len(refs) - len(repos); missing > 0 {
+		fmt.Printf("  %d links didn't resolve to an accessible repo\n", missing)
+	}
+
+	fmt.Println("Connecting to SurrealDB...")
+	db, err := surrealdb.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close(ctx) }()
+	if err := db.InitSchema(ctx); err != nil {
+		return err
+	}
+
+	// Same as a sync: move legacy owner/name keys over to node IDs before
+	// the upsert, or imported repos would duplicate them.
+	if err := migrateLegacyKeys(ctx, db, ghs, repos); err != nil {
+		return err
+	}
+	if err := upsertRepos(ctx, cfg, db, repos); err != nil {
+		return err
+	}
+
+	reportMissingReadmes(repos)
+
+	if opts.SkipEnrich {
+		fmt.Println("Skipping enrichment (--skip-enrich)")
+		return nil
+	}
+	if err := Enrich(ctx, cfg, db, false, repos); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d repos from %s\n", len(repos), label)
+	return nil
+}
@@ -3,11 +3,15 @@ package pipeline
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/kevinmichaelchen/star-watch/internal/config"
+	"github.com/kevinmichaelchen/star-watch/internal/cost"
 	"github.com/kevinmichaelchen/star-watch/internal/embedding"
 	"github.com/kevinmichaelchen/star-watch/internal/github"
 	"github.com/kevinmichaelchen/star-watch/internal/llm"
@@ -16,12 +20,71 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-const cacheFile = "stars.json"
-
 type Options struct {
-	SkipEnrich bool
-	Force      bool
-	Refresh    bool
+	SkipEnrich   bool // same as skipping the enrich and embed stages
+	SkipReleases bool // same as skipping the releases stage
+	Force        bool
+	Refresh      bool
+	ListIDs      []string // star lists to sync; defaults to cfg.StarListIDs
+	Only         []string // run just these stages
+	Skip         []string // run every stage but these
+	Resume       bool     // continue the last run if it didn't finish
+	DryRun       bool     // report what would run and its cost, writing nothing
+	MaxCost      float64  // USD spend limit; 0 uses cfg.MaxCost
+	MaxTokens    int      // token limit; 0 uses cfg.MaxTokens
+}
+
+// cacheFile returns the per-list cache path.
+func cacheFile(listRef string) string {
+	return fmt.Sprintf("stars-%s.json", strings.ReplaceAll(listRef, ":", "-"))
+}
+
+// splitListRef splits a list reference of the form "[source:]list". The
+// prefix only selects a source if it names one in sources, so list names
+// containing ":" (e.g. "Go: web") belong to the default (github.com) source;
+// a leading ":" selects the default source explicitly.
+func splitListRef(ref string, sources map[string]config.GitHubSource) (source, listID string) {
+	if source, listID, ok := strings.Cut(ref, ":"); ok {
+		if _, known := sources[source]; known {
+			return source, listID
+		}
+	}
+	return "", ref
+}
+
+// GitHubClient creates a client for a configured GitHub source ("" is the
+// default source).
+func GitHubClient(cfg *config.Config, source string) (*github.Client, error) {
+	src, ok := cfg.GitHubSources[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown GitHub source %q (add it to GITHUB_SOURCES)", source)
+	}
+	httpClient, err := github.NewHTTPClient(src.CABundle, src.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub source %q: %w", source, err)
+	}
+	return github.NewClient(src.GraphQLURL, src.Token, httpClient), nil
+}
+
+// githubClients lazily creates one GitHub client per configured source.
+type githubClients struct {
+	cfg     *config.Config
+	clients map[string]*github.Client
+}
+
+func (g *githubClients) get(source string) (*github.Client, error) {
+	if c, ok := g.clients[source]; ok {
+		return c, nil
+	}
+	c, err := GitHubClient(g.cfg, source)
+	if err != nil {
+		return nil, err
+	}
+	if g.clients == nil {
+		g.clients = map[string]*github.Client{}
+	}
+	g.clients[source] = c
+	return c, nil
 }
 
 func Run(ctx context.Context, cfg *config.Config, opts Options) error {
@@ -34,151 +97,792 @@ func Run(ctx context.Context, cfg *config.Config, opts Options) error {
 	defer func() { _ = db.Close(ctx) }()
 
 	// Ensure schema
-	if err := db.InitSchema(ctx); err != nil {
+	if !opts.DryRun {
+		if err := db.InitSchema(ctx); err != nil {
+			return err
+		}
+	}
+
+	var run surrealdb.SyncRun
+	if opts.Resume {
+		last, err := db.GetLatestSyncRun(ctx)
+		if err != nil {
+			return err
+		}
+		if last == nil || last.FinishedAt != nil {
+			fmt.Println("The last sync finished; nothing to resume")
+			return nil
+		}
+		run = *last
+		fmt.Printf("Resuming sync %s (done: %s)\n", run.ID, strings.Join(run.StagesDone, ", "))
+		// Rerun with the interrupted run's options. The cache written by its
+		// fetch makes refetching cheap.
+		opts.Force, opts.Only, opts.Skip, opts.ListIDs = run.Force, run.Only, run.Skip, run.ListIDs
+		opts.Refresh = false
+	} else {
+		skip := opts.Skip
+		if opts.SkipEnrich {
+			skip = append(skip, StageEnrich, StageEmbed)
+		}
+		if opts.SkipReleases {
+			skip = append(skip, StageReleases)
+		}
+		run = surrealdb.SyncRun{
+			ID:      time.Now().UTC().Format("20060102T150405Z"),
+			Force:   opts.Force,
+			Only:    opts.Only,
+			Skip:    skip,
+			ListIDs: opts.ListIDs,
+		}
+		opts.Skip = skip
+	}
+
+	plan, err := planStages(opts.Only, opts.Skip)
+	if err != nil {
 		return err
 	}
+	plan = pendingStages(plan, run.StagesDone)
+	if len(plan) == 0 {
+		fmt.Println("No stages to run")
+		return nil
+	}
+	s := &syncState{cfg: cfg, opts: opts, db: db, ghs: &githubClients{cfg: cfg}, runID: run.ID, planned: map[string]bool{}}
+	for _, st := range plan {
+		s.planned[st.name] = true
+	}
+	if opts.DryRun {
+		return dryRun(ctx, s, plan)
+	}
 
-	// Step 1: Load repos (from cache or GitHub)
-	repos, err := loadRepos(ctx, cfg, opts.Refresh)
+	var used []string // models whose spend the budget must price
+	if s.planned[StageEnrich] {
+		used = append(used, cfg.LLMModel)
+	}
+	if s.planned[StageEmbed] {
+		used = append(used, cfg.EmbeddingModel)
+	}
+	if s.budget, err = newBudget(cfg, opts.MaxCost, opts.MaxTokens, used...); err != nil {
+		return err
+	}
+	if !opts.Resume {
+		if err := db.StartSyncRun(ctx, run); err != nil {
+			return err
+		}
+	}
+	for i, st := range plan {
+		if err := st.run(ctx, s); err != nil {
+			if errors.Is(err, cost.ErrBudgetExceeded) {
+				// Reaching a configured limit is a clean stop: results so
+				// far are stored, and leaving the stage unfinished lets
+				// --resume continue from here.
+				var rest []string
+				for _, next := range plan[i:] {
+					rest = append(rest, next.name)
+				}
+				fmt.Printf("Stopped: %v\n", err)
+				fmt.Printf("Spent %s. Not completed: %s\n", s.budget, strings.Join(rest, ", "))
+				fmt.Println("Raise the limit and run `star-watch sync --resume` to continue")
+				return nil
+			}
+			return fmt.Errorf("%s: %w", st.name, err)
+		}
+		if err := db.MarkStageDone(ctx, run.ID, st.name); err != nil {
+			return err
+		}
+	}
+	if err := db.FinishSyncRun(ctx, run.ID); err != nil {
+		return err
+	}
+
+	if s.budget.Used() {
+		fmt.Printf("Spent %s\n", s.budget)
+	}
+	fmt.Println("Sync complete!")
+	return nil
+}
+
+// newBudget sets up the spend limits of a run that calls models. Non-zero
+// arguments override the MAX_COST and MAX_TOKENS config. A cost limit is
+// refused when one of the models has no price, since it couldn't be
+// enforced.
+func newBudget(cfg *config.Config, maxCost float64, maxTokens int, models ...string) (*cost.Budget, error) {
+	if maxCost == 0 {
+		maxCost = cfg.MaxCost
+	}
+	if maxTokens == 0 {
+		maxTokens = cfg.MaxTokens
+	}
+	prices, err := cost.LoadPrices(cfg.ModelPrices)
+	if err != nil {
+		return nil, fmt.Errorf("MODEL_PRICES: %w", err)
+	}
+	b := cost.NewBudget(prices, maxCost, maxTokens)
+	if maxCost > 0 {
+		for _, model := range models {
+			if !b.Priced(model) {
+				return nil, fmt.Errorf("cost limit set but %s has no price; add it to MODEL_PRICES", model)
+			}
+		}
+	}
+	return b, nil
+}
+
+// fetchStage loads repos for each list, from cache or GitHub, and refreshes
+// the counts of cached repos.
+func fetchStage(ctx context.Context, s *syncState) error {
+	return fetchLists(ctx, s, true)
+}
+
+// estimateFetch is fetchStage for dry runs: list caches are read and
+// fetched lists are reported, but caches aren't written and counts aren't
+// refreshed.
+func estimateFetch(ctx context.Context, s *syncState) error {
+	return fetchLists(ctx, s, false)
+}
+
+// fetchLists loads the repos of each list into s. With persist unset,
+// nothing is written to the list caches.
+func fetchLists(ctx context.Context, s *syncState, persist bool) error {
+	start := time.Now()
+	listIDs := s.opts.ListIDs
+	if len(listIDs) == 0 {
+		listIDs = s.cfg.StarListIDs
+	}
+	if len(listIDs) == 0 {
+		return fmt.Errorf("no star lists configured (set STAR_LIST_ID or pass --list)")
+	}
+
+	// Lists may be given by name or slug; resolve them to node IDs so caches
+	// and membership edges are keyed consistently.
+	listIDs, err := resolveListRefs(ctx, s.ghs, listIDs)
 	if err != nil {
 		return err
 	}
 
-	// Step 2: Upsert repos into SurrealDB
-	fmt.Println("Upserting repos into SurrealDB...")
-	for i, repo := range repos {
-		if err := db.UpsertRepo(ctx, repo); err != nil {
+	members := make(map[string][]models.Repo, len(listIDs))
+	var repos []models.Repo
+	seen := map[string]int{}     // repo key → index into repos
+	cached := map[string][]int{} // source → indices into repos of cached repos
+	for _, ref := range listIDs {
+		fmt.Printf("Star list %s:\n", ref)
+		source, listID := splitListRef(ref, s.cfg.GitHubSources)
+		gh, err := s.ghs.get(source)
+		if err != nil {
 			return err
 		}
-		if (i+1)%50 == 0 || i+1 == len(repos) {
-			fmt.Printf("  Upserted %d/%d\n", i+1, len(repos))
+		listRepos, err := loadRepos(ctx, gh, ref, listID, s.opts.Refresh, persist)
+		if err != nil {
+			return err
+		}
+		members[ref] = listRepos
+		for _, repo := range listRepos {
+			// A repo in several lists is stored once. Only the viewer's
+			// own stars carry StarredAt, so keep the earliest one found.
+			key := surrealdb.RepoKey(repo)
+			if i, ok := seen[key]; ok {
+				if at := repo.StarredAt; at != nil && (repos[i].StarredAt == nil || at.Before(*repos[i].StarredAt)) {
+					repos[i].StarredAt = at
+				}
+				continue
+			}
+			seen[key] = len(repos)
+			if repo.FetchedAt == nil || repo.FetchedAt.Before(start) {
+				cached[source] = append(cached[source], len(repos))
+			}
+			repos = append(repos, repo)
 		}
 	}
 
-	if opts.SkipEnrich {
-		fmt.Println("Skipping enrichment (--skip-enrich)")
-		return nil
+	for source, idx := range cached {
+		if !persist {
+			break // a dry run only reports the lists
+		}
+		gh, err := s.ghs.get(source)
+		if err != nil {
+			return err
+		}
+		refreshCounts(ctx, gh, repos, idx, start)
+	}
+
+	s.listIDs, s.members, s.repos = listIDs, members, repos
+	return nil
+}
+
+// refreshCounts updates the star, fork and open issue counts of the cached
+// repos at idx and stamps them with the sync time, so every sync adds a
+// star history point. Repos that can't be looked up keep their cached
+// counts and fetch time.
+func refreshCounts(ctx context.Context, gh *github.Client, repos []models.Repo, idx []int, now time.Time) {
+	refs := make([]github.RepoRef, len(idx))
+	for i, j := range idx {
+		refs[i] = github.RepoRef{Owner: repos[j].Owner, Name: repos[j].Name}
+	}
+	fmt.Printf("Refreshing star counts of %d cached repos...\n", len(refs))
+	counts, err := gh.LookupCounts(ctx, refs)
+	if err != nil {
+		fmt.Printf("  WARN: keeping cached counts: %v\n", err)
+		return
+	}
+	for i, j := range idx {
+		if c, ok := counts[refs[i]]; ok {
+			repos[j].Stars, repos[j].Forks, repos[j].OpenIssues = c.Stars, c.Forks, c.OpenIssues
+			repos[j].FetchedAt = &now
+		}
+	}
+	if missing := len(refs) - len(counts); missing > 0 {
+		fmt.Printf("  %d repos could not be looked up\n", missing)
+	}
+}
+
+// upsertStage stores fetched repos, records list membership and tombstones
+// repos that left every list.
+func upsertStage(ctx context.Context, s *syncState) error {
+	// Move records keyed by owner/name over to node IDs before the upsert,
+	// so fetched data lands on the re-keyed record rather than on a fresh
+	// one the legacy record would then have to be merged into.
+	if err := migrateLegacyKeys(ctx, s.db, s.ghs, s.repos); err != nil {
+		return err
+	}
+
+	if err := upsertRepos(ctx, s.cfg, s.db, s.repos); err != nil {
+		return err
+	}
+
+	// Record list membership as repo->in_list->list edges
+	fmt.Println("Updating list membership...")
+	for _, ref := range s.listIDs {
+		source, listID := splitListRef(ref, s.cfg.GitHubSources)
+		name := ""
+		if listID == github.StarredListID {
+			name = "Starred"
+		} else if gh, err := s.ghs.get(source); err != nil {
+			return err
+		} else if list, err := gh.FetchList(ctx, listID); err != nil {
+			fmt.Printf("  WARN: could not fetch name of list %s: %v\n", ref, err)
+		} else {
+			name = list.Name
+		}
+		if err := s.db.SyncListMembership(ctx, ref, name, s.members[ref]); err != nil {
+			return err
+		}
+		fmt.Printf("  %s: %d repos\n", listLabel(ref, name), len(s.members[ref]))
+	}
+
+	// Forget lists dropped from the configuration. Lists picked with --list
+	// are a subset, so the rest are left alone then.
+	if len(s.opts.ListIDs) == 0 {
+		dropped, err := s.db.DropOtherLists(ctx, s.listIDs)
+		if err != nil {
+			return err
+		}
+		for _, label := range dropped {
+			fmt.Printf("  %s: no longer configured, membership dropped\n", label)
+		}
+	}
+
+	// Tombstone repos that are no longer in any list
+	removed, err := s.db.ReconcileRemoved(ctx)
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		fmt.Printf("Marked %d repos as removed (run `star-watch prune` to delete)\n", removed)
+	}
+
+	reportMissingReadmes(s.repos)
+	return nil
+}
+
+// upsertRepos stores repos in transactional batches. Repos that fail on
+// their own are reported and left out; only a broken connection or
+// cancellation stops the sync.
+func upsertRepos(ctx context.Context, cfg *config.Config, db *surrealdb.Client, repos []models.Repo) error {
+	fmt.Println("Upserting repos into SurrealDB...")
+	failed, err := db.UpsertRepos(ctx, repos, cfg.SurrealBatchSize, func(done int) {
+		fmt.Printf("  Upserted %d/%d\n", done, len(repos))
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range failed {
+		fmt.Printf("  WARN: %s: %v\n", f.FullName, f.Err)
+	}
+	if len(failed) > 0 {
+		fmt.Printf("  %d of %d repos could not be stored\n", len(failed), len(repos))
+	}
+	return nil
+}
+
+func releasesStage(ctx context.Context, s *syncState) error {
+	return syncReleases(ctx, s.db, s.ghs, s.listIDs, s.members)
+}
+
+func enrichStage(ctx context.Context, s *syncState) error {
+	return enrichRepos(ctx, s.cfg, s.db, s.budget, enrichOptions{force: s.opts.Force, runID: s.runID, embedAlong: s.planned[StageEmbed]})
+}
+
+func embedStage(ctx context.Context, s *syncState) error {
+	return embedRepos(ctx, s.cfg, s.db, s.budget, enrichOptions{force: s.opts.Force, runID: s.runID})
+}
+
+// Enrich summarizes repos that are new or whose summary input changed, and
+// embeds repos whose summary is newer than their embedding. With force, all
+// repos are summarized and embedded. Only the given repos are considered,
+// or every repo if repos is nil. Spending is capped by MAX_COST and
+// MAX_TOKENS.
+func Enrich(ctx context.Context, cfg *config.Config, db *surrealdb.Client, force bool, repos []models.Repo) error {
+	budget, err := newBudget(cfg, 0, 0, cfg.LLMModel, cfg.EmbeddingModel)
+	if err != nil {
+		return err
 	}
+	opts := enrichOptions{force: force, embedAlong: true}
+	if repos != nil {
+		opts.scope = make(map[string]bool, len(repos))
+		for _, r := range repos {
+			opts.scope[surrealdb.RepoKey(r)] = true
+		}
+	}
+	err = enrichRepos(ctx, cfg, db, budget, opts)
+	if err == nil {
+		err = embedRepos(ctx, cfg, db, budget, opts)
+	}
+	if errors.Is(err, cost.ErrBudgetExceeded) {
+		// The rest is picked up by the next sync or import.
+		fmt.Printf("Stopped: %v\n", err)
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	if budget.Used() {
+		fmt.Printf("Spent %s\n", budget)
+	}
+	return nil
+}
 
-	// Step 3: Find repos needing enrichment
-	var toEnrich []models.Repo
-	if opts.Force {
+// enrichOptions selects what enrichRepos and embedRepos process.
+type enrichOptions struct {
+	force      bool            // redo repos that are up to date
+	runID      string          // sync run to tag results with; "" for none
+	embedAlong bool            // embed summaries while enriching
+	scope      map[string]bool // repo keys to consider; nil means all
+}
+
+// inScope keeps the repos whose key is in scope, or all of them if scope is
+// nil.
+func inScope(repos []models.Repo, scope map[string]bool) []models.Repo {
+	if scope == nil {
+		return repos
+	}
+	var out []models.Repo
+	for _, r := range repos {
+		if scope[surrealdb.RepoKey(r)] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// enrichRepos generates AI summaries and categories. Each stored result is
+// tagged with runID (if set), so a forced run that is interrupted and
+// resumed skips repos it already re-enriched. With embedAlong, summaries
+// are embedded in batches while enrichment continues, so an interrupted run
+// leaves most enriched repos searchable; embedRepos picks up the rest.
+// Once budget is exhausted no more repos are dispatched; summaries already
+// in flight are stored and the budget error is returned.
+func enrichRepos(ctx context.Context, cfg *config.Config, db *surrealdb.Client, budget *cost.Budget, opts enrichOptions) error {
+	force, runID := opts.force, opts.runID
+	llmClient := llm.NewClient(cfg.LLMBaseURL, cfg.LLMAPIKey, cfg.LLMModel)
+	llmClient.SetBudget(budget)
+
+	var (
+		toEnrich []models.Repo
+		err      error
+	)
+	switch {
+	case force && runID != "":
+		toEnrich, err = db.GetReposToReenrich(ctx, runID)
+	case force:
 		toEnrich, err = db.GetAllRepos(ctx)
-	} else {
-		toEnrich, err = db.GetUnenrichedRepos(ctx)
+	default:
+		toEnrich, err = staleRepos(ctx, db, llmClient)
 	}
 	if err != nil {
 		return err
 	}
+	toEnrich = inScope(toEnrich, opts.scope)
 
 	if len(toEnrich) == 0 {
 		fmt.Println("All repos already enriched")
-	} else {
-		// Step 4: Generate AI summaries
-		fmt.Printf("Enriching %d repos with AI summaries...\n", len(toEnrich))
-		llmClient := llm.NewClient(cfg.LLMBaseURL, cfg.LLMAPIKey, cfg.LLMModel)
-
-		var done atomic.Int64
-		g, gCtx := errgroup.WithContext(ctx)
-		g.SetLimit(5)
-
-		for _, repo := range toEnrich {
-			repo := repo
-			g.Go(func() error {
-				result, err := llmClient.Summarize(gCtx, repo)
-				if err != nil {
-					fmt.Printf("  WARN: %v\n", err)
-					return nil // continue with other repos
-				}
+		return nil
+	}
 
-				if err := db.UpdateEnrichment(gCtx, repo.FullName, result.Summary, result.Categories); err != nil {
-					fmt.Printf("  WARN: storing enrichment for %s: %v\n", repo.FullName, err)
-					return nil
-				}
+	fmt.Printf("Enriching %d repos with AI summaries...\n", len(toEnrich))
 
-				n := done.Add(1)
-				if n%10 == 0 || int(n) == len(toEnrich) {
-					fmt.Printf("  Enriched %d/%d\n", n, len(toEnrich))
-				}
-				return nil
-			})
+	var batcher *embedBatcher
+	if opts.embedAlong {
+		embClient := embedding.NewClient(cfg.EmbeddingBaseURL, cfg.EmbeddingAPIKey, cfg.EmbeddingModel)
+		embClient.SetBudget(budget)
+		batcher = startEmbedBatcher(ctx, streamFlushAfter, func(ctx context.Context, repos []models.Repo) (int, error) {
+			return embedAndStore(ctx, embClient, db, runID, repos)
+		})
+	}
+
+	var done atomic.Int64
+	dispatched, err := dispatchWithin(ctx, budget, 5, toEnrich, func(ctx context.Context, repo models.Repo) {
+		result, err := llmClient.Summarize(ctx, repo)
+		if err != nil {
+			fmt.Printf("  WARN: %v\n", err)
+			return // continue with other repos
 		}
 
-		if err := g.Wait(); err != nil {
-			return err
+		enrichment := surrealdb.Enrichment{
+			Summary:       result.Summary,
+			Categories:    result.Categories,
+			InputHash:     llmClient.InputHash(repo),
+			Model:         llmClient.Model(),
+			PromptVersion: llm.PromptVersion,
+		}
+		if err := db.UpdateEnrichment(ctx, repo, runID, enrichment); err != nil {
+			fmt.Printf("  WARN: storing enrichment for %s: %v\n", repo.FullName, err)
+			return
+		}
+		if batcher != nil {
+			repo.AISummary = &result.Summary
+			batcher.Add(repo)
+		}
+
+		n := done.Add(1)
+		if n%10 == 0 || int(n) == len(toEnrich) {
+			fmt.Printf("  Enriched %d/%d\n", n, len(toEnrich))
+		}
+	})
+	if errors.Is(err, cost.ErrBudgetExceeded) {
+		fmt.Printf("  %d repos left to enrich\n", len(toEnrich)-dispatched)
+	}
+	if batcher != nil {
+		stored, embedErr := batcher.Close()
+		fmt.Printf("Stored %d embeddings alongside enrichment\n", stored)
+		if err == nil {
+			err = embedErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Enrichment complete (%d repos)\n", done.Load())
+	return nil
+}
+
+// dispatchWithin runs work for each repo on up to workers goroutines. The
+// budget is checked right before each repo starts; once it is exhausted no
+// more repos are dispatched, and after those in flight finish the budget
+// error is returned along with how many repos were dispatched.
+func dispatchWithin(ctx context.Context, budget *cost.Budget, workers int, repos []models.Repo, work func(ctx context.Context, repo models.Repo)) (int, error) {
+	g, gCtx := errgroup.WithContext(ctx)
+
+	// Take a worker slot before checking the budget, so the check sees the
+	// usage of every repo that finished to free it.
+	slots := make(chan struct{}, workers)
+	var stopped error
+	dispatched := 0
+	for _, repo := range repos {
+		slots <- struct{}{}
+		if stopped = budget.Check(); stopped != nil {
+			break
+		}
+		dispatched++
+		g.Go(func() error {
+			defer func() { <-slots }()
+			work(gCtx, repo)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return dispatched, err
+	}
+	return dispatched, stopped
+}
+
+// staleRepos returns repos that were never enriched or whose summary input
+// (description, README excerpt, model or prompt version) changed since, and
+// reports why. Summaries written before input hashes were tracked are
+// assumed current and get their hash recorded.
+func staleRepos(ctx context.Context, db *surrealdb.Client, llmClient *llm.Client) ([]models.Repo, error) {
+	repos, err := db.GetEnrichmentCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stale, baseline := selectStale(repos, llmClient)
+	if len(baseline) > 0 {
+		if err := db.RecordEnrichmentInputs(ctx, baseline); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Recorded input hashes for %d previously enriched repos\n", len(baseline))
+	}
+	return stale, nil
+}
+
+// selectStale splits repos into those whose summary is missing or stale,
+// reporting why, and those with a summary but no recorded input hash yet,
+// which get one filled in.
+func selectStale(repos []models.Repo, llmClient *llm.Client) (stale, baseline []models.Repo) {
+	reasons := map[string]int{}
+	for _, r := range repos {
+		hash := llmClient.InputHash(r)
+		reason := ""
+		switch {
+		case r.AISummary == nil:
+			reason = "new"
+		case r.EnrichHash == nil:
+			model, prompt := llmClient.Model(), llm.PromptVersion
+			r.EnrichHash, r.EnrichModel, r.EnrichPrompt = &hash, &model, &prompt
+			baseline = append(baseline, r)
+		case r.EnrichModel == nil || *r.EnrichModel != llmClient.Model():
+			reason = "model changed"
+		case r.EnrichPrompt == nil || *r.EnrichPrompt != llm.PromptVersion:
+			reason = "prompt changed"
+		case *r.EnrichHash != hash:
+			reason = "description or README changed"
+		}
+		if reason != "" {
+			reasons[reason]++
+			stale = append(stale, r)
 		}
-		fmt.Printf("Enrichment complete (%d repos)\n", done.Load())
 	}
 
-	// Step 5: Generate embeddings
-	var toEmbed []models.Repo
-	if opts.Force {
+	if refreshed := len(stale) - reasons["new"]; refreshed > 0 {
+		fmt.Printf("Refreshing %d stale summaries:\n", refreshed)
+		for _, reason := range []string{"model changed", "prompt changed", "description or README changed"} {
+			if n := reasons[reason]; n > 0 {
+				fmt.Printf("  %s: %d\n", reason, n)
+			}
+		}
+	}
+	return stale, baseline
+}
+
+// embedRepos generates embeddings from names and AI summaries, tagging
+// each with runID like enrichRepos. It stops between batches once budget
+// is exhausted.
+func embedRepos(ctx context.Context, cfg *config.Config, db *surrealdb.Client, budget *cost.Budget, opts enrichOptions) error {
+	force, runID := opts.force, opts.runID
+	var (
+		toEmbed []models.Repo
+		err     error
+	)
+	switch {
+	case force && runID != "":
+		toEmbed, err = db.GetReposToReembed(ctx, runID)
+	case force:
 		toEmbed, err = db.GetAllRepos(ctx)
-	} else {
+	default:
 		toEmbed, err = db.GetReposNeedingEmbedding(ctx)
 	}
 	if err != nil {
 		return err
 	}
+	toEmbed = inScope(toEmbed, opts.scope)
 
 	if len(toEmbed) == 0 {
 		fmt.Println("All repos already have embeddings")
-	} else {
-		fmt.Printf("Generating embeddings for %d repos...\n", len(toEmbed))
-		embClient := embedding.NewClient(cfg.EmbeddingBaseURL, cfg.EmbeddingAPIKey, cfg.EmbeddingModel)
+		return nil
+	}
 
-		// Build input texts
-		texts := make([]string, len(toEmbed))
-		for i, repo := range toEmbed {
-			summary := ""
-			if repo.AISummary != nil {
-				summary = *repo.AISummary
+	fmt.Printf("Generating embeddings for %d repos...\n", len(toEmbed))
+	if !force {
+		updated := 0
+		for _, repo := range toEmbed {
+			if repo.Embedding != nil {
+				updated++
 			}
-			texts[i] = fmt.Sprintf("%s: %s", repo.FullName, summary)
 		}
+		if updated > 0 {
+			fmt.Printf("  %d of them have updated summaries\n", updated)
+		}
+	}
+	embClient := embedding.NewClient(cfg.EmbeddingBaseURL, cfg.EmbeddingAPIKey, cfg.EmbeddingModel)
+	embClient.SetBudget(budget)
+
+	stored, err := embedAndStore(ctx, embClient, db, runID, toEmbed)
+	fmt.Printf("Stored %d embeddings\n", stored)
+	return err
+}
+
+// embedAndStore embeds repos from their names and AI summaries, storing
+// each batch as soon as it is embedded. Repos that can't be embedded are
+// listed and skipped; they are retried on the next sync. It returns how
+// many embeddings were stored.
+func embedAndStore(ctx context.Context, embClient *embedding.Client, db *surrealdb.Client, runID string, repos []models.Repo) (int, error) {
+	texts := make([]string, len(repos))
+	for i, repo := range repos {
+		texts[i] = embeddingText(repo)
+	}
+
+	stored := 0
+	failed, err := embClient.EmbedStream(ctx, texts, func(indices []int, vectors [][]float32) error {
+		batch := make([]models.Repo, len(indices))
+		for i, idx := range indices {
+			batch[i] = repos[idx]
+		}
+		if err := db.UpdateEmbeddings(ctx, batch, runID, vectors); err != nil {
+			return err
+		}
+		stored += len(batch)
+		return nil
+	})
+	if len(failed) > 0 {
+		fmt.Printf("  Could not embed %d repos:\n", len(failed))
+		for _, f := range failed {
+			fmt.Printf("    %s: %v\n", repos[f.Index].FullName, f.Err)
+		}
+	}
+	if err != nil {
+		if left := len(repos) - stored - len(failed); left > 0 {
+			fmt.Printf("  %d repos left to embed\n", left)
+		}
+		return stored, fmt.Errorf("generating embeddings: %w", err)
+	}
+	return stored, nil
+}
+
+// embeddingText is what a repo's embedding is generated from.
+func embeddingText(repo models.Repo) string {
+	summary := ""
+	if repo.AISummary != nil {
+		summary = *repo.AISummary
+	}
+	return fmt.Sprintf("%s: %s", repo.FullName, summary)
+}
 
-		vectors, err := embClient.Embed(ctx, texts)
+// syncReleases fetches the latest releases of every synced repo from its
+// list's source and stores them. A repo in several lists is fetched once.
+func syncReleases(ctx context.Context, db *surrealdb.Client, ghs *githubClients, listIDs []string, members map[string][]models.Repo) error {
+	fmt.Println("Fetching releases...")
+	seen := map[string]bool{}
+	total := 0
+	for _, ref := range listIDs {
+		source, _ := splitListRef(ref, ghs.cfg.GitHubSources)
+		gh, err := ghs.get(source)
 		if err != nil {
-			return fmt.Errorf("generating embeddings: %w", err)
+			return err
+		}
+
+		var batch []models.Repo
+		for _, r := range members[ref] {
+			if key := surrealdb.RepoKey(r); !seen[key] {
+				seen[key] = true
+				batch = append(batch, r)
+			}
+		}
+		if len(batch) == 0 {
+			continue
 		}
 
-		// Store embeddings
-		fmt.Println("Storing embeddings...")
-		for i, repo := range toEmbed {
-			if err := db.UpdateEmbedding(ctx, repo.FullName, vectors[i]); err != nil {
-				fmt.Printf("  WARN: storing embedding for %s: %v\n", repo.FullName, err)
+		releases, err := gh.FetchReleases(ctx, batch)
+		if err != nil {
+			fmt.Printf("  WARN: %v\n", err)
+			continue
+		}
+		for _, r := range batch {
+			if err := db.UpsertReleases(ctx, r, releases[r.FullName]); err != nil {
+				fmt.Printf("  WARN: %v\n", err)
 				continue
 			}
+			total += len(releases[r.FullName])
 		}
-		fmt.Printf("Stored %d embeddings\n", len(vectors))
 	}
-
-	fmt.Println("Sync complete!")
+	fmt.Printf("  Stored %d releases\n", total)
 	return nil
 }
 
-func loadRepos(ctx context.Context, cfg *config.Config, refresh bool) ([]models.Repo, error) {
-	gh := github.NewClient(cfg.GitHubToken)
-	cached, cacheErr := readCache()
+// reportMissingReadmes lists repos for which no README candidate was found,
+// and those whose README had nothing left after cleaning; their summaries
+// will be based on the description alone.
+func reportMissingReadmes(repos []models.Repo) {
+	var missing, empty []string
+	for _, r := range repos {
+		switch {
+		case r.ReadmeExcerpt != nil:
+		case r.ReadmePath != nil:
+			empty = append(empty, r.FullName)
+		default:
+			missing = append(missing, r.FullName)
+		}
+	}
+	printNames(fmt.Sprintf("No README found for %d repos:", len(missing)), missing)
+	printNames(fmt.Sprintf("README empty after cleaning for %d repos:", len(empty)), empty)
+}
+
+// printNames prints header and up to ten names, or nothing if names is
+// empty.
+func printNames(header string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	const maxShown = 10
+	fmt.Println(header)
+	for i, name := range names {
+		if i == maxShown {
+			fmt.Printf("  ... and %d more\n", len(names)-maxShown)
+			break
+		}
+		fmt.Printf("  %s\n", name)
+	}
+}
+
+// listLabel formats a list for log output, preferring its name.
+func listLabel(listID, name string) string {
+	if name == "" {
+		return listID
+	}
+	return fmt.Sprintf("%s (%s)", name, listID)
+}
+
+// strategies returns the full and incremental fetch strategies for a list.
+// The StarredListID pseudo list uses StarredStrategy for both.
+func strategies(listID string) (full, incremental github.Strategy) {
+	if listID == github.StarredListID {
+		return github.StarredStrategy{}, github.StarredStrategy{}
+	}
+	return github.ForwardStrategy{}, github.IncrementalStrategy{}
+}
 
-	// --refresh: discard cache and do a full forward fetch
+// loadRepos returns the repos of one list. ref is the list reference used
+// for caching; listID is the bare node ID sent to GitHub. The cache is only
+// written with persist set.
+func loadRepos(ctx context.Context, gh *github.Client, ref, listID string, refresh, persist bool) ([]models.Repo, error) {
+	cached, cacheErr := readCache(ref)
+	full, incremental := strategies(listID)
+
+	// --refresh: discard cache and do a full fetch
 	if refresh {
 		fmt.Println("Fetching star list from GitHub (full refresh)...")
-		return fetchAndCache(ctx, gh, cfg.StarListID, github.ForwardStrategy{}, nil)
+		return fetchAndCache(ctx, gh, ref, listID, full, nil, persist)
+	}
+
+	// Caches written before repos were keyed on node IDs can't be reused
+	if cacheErr == nil && len(cached) > 0 && !hasNodeIDs(cached) {
+		fmt.Println("Cache predates node IDs. Fetching star list from GitHub...")
+		return fetchAndCache(ctx, gh, ref, listID, full, nil, persist)
 	}
 
 	// Cache exists: try incremental fetch for new repos
 	if cacheErr == nil && len(cached) > 0 {
 		fmt.Printf("Cache has %d repos. Checking for new stars...\n", len(cached))
-		repos, err := github.IncrementalStrategy{}.Fetch(ctx, gh, cfg.StarListID, cached)
+		repos, err := incremental.Fetch(ctx, gh, listID, cached)
 		if err != nil {
 			fmt.Printf("  WARN: incremental fetch failed (%v), using cache as-is\n", err)
 			return cached, nil
 		}
-		if len(repos) > len(cached) {
-			fmt.Printf("Found %d new repos (%d total)\n", len(repos)-len(cached), len(repos))
-			if err := writeCache(repos); err != nil {
-				fmt.Printf("  WARN: could not update %s: %v\n", cacheFile, err)
+		if changed(cached, repos) {
+			fmt.Printf("Star list changed (%d → %d repos)\n", len(cached), len(repos))
+			if persist {
+				if err := writeCache(ref, repos); err != nil {
+					fmt.Printf("  WARN: could not update %s: %v\n", cacheFile(ref), err)
+				}
 			}
 		} else {
 			fmt.Printf("Cache is up to date (%d repos)\n", len(cached))
@@ -186,28 +890,153 @@ func loadRepos(ctx context.Context, cfg *config.Config, refresh bool) ([]models.
 		return repos, nil
 	}
 
-	// No cache: full forward fetch
+	// No cache: full fetch
 	fmt.Println("Fetching star list from GitHub...")
-	return fetchAndCache(ctx, gh, cfg.StarListID, github.ForwardStrategy{}, nil)
+	return fetchAndCache(ctx, gh, ref, listID, full, nil, persist)
+}
+
+func resolveListRefs(ctx context.Context, ghs *githubClients, refs []string) ([]string, error) {
+	out := make([]string, len(refs))
+	for i, ref := range refs {
+		source, listID := splitListRef(ref, ghs.cfg.GitHubSources)
+		gh, err := ghs.get(source)
+		if err != nil {
+			return nil, err
+		}
+		id, err := gh.ResolveListID(ctx, listID)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = id
+		if source != "" {
+			out[i] = source + ":" + id
+		}
+		if id != listID {
+			fmt.Printf("Resolved star list %q to %s\n", ref, out[i])
+		}
+	}
+	return out, nil
+}
+
+func hasNodeIDs(repos []models.Repo) bool {
+	for _, r := range repos {
+		if r.NodeID == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// migrateLegacyKeys re-keys repo records that predate node IDs. Records
+// matching a fetched repo by host and name take its IDs; the rest are looked
+// up on github.com, which follows renames and transfers. Records that can't
+// be resolved (deleted repos, or GitHub Enterprise repos no longer in any
+// synced list) keep their legacy key.
+func migrateLegacyKeys(ctx context.Context, db *surrealdb.Client, ghs *githubClients, repos []models.Repo) error {
+	legacy, err := db.GetLegacyRepos(ctx)
+	if err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+	fmt.Printf("Migrating %d repos to node ID keys...\n", len(legacy))
+
+	byName := make(map[string]models.Repo, len(repos))
+	for _, r := range repos {
+		byName[hostOrDefault(r.Host)+"/"+r.FullName] = r
+	}
+
+	targets := make(map[string]models.Repo, len(legacy))
+	var refs []github.RepoRef
+	refKeys := map[github.RepoRef]string{}
+	for _, l := range legacy {
+		host := hostOrDefault(l.Host)
+		if r, ok := byName[host+"/"+l.FullName]; ok {
+			targets[l.Key] = r
+			continue
+		}
+		if host == "github.com" {
+			ref := github.RepoRef{Owner: l.Owner, Name: l.Name}
+			refs = append(refs, ref)
+			refKeys[ref] = l.Key
+		}
+	}
+
+	if len(refs) > 0 {
+		gh, err := ghs.get("")
+		if err != nil {
+			return err
+		}
+		ids, err := gh.LookupIDs(ctx, refs)
+		if err != nil {
+			return fmt.Errorf("looking up node IDs: %w", err)
+		}
+		for ref, id := range ids {
+			targets[refKeys[ref]] = models.Repo{
+				NodeID:     id.NodeID,
+				DatabaseID: id.DatabaseID,
+				FullName:   id.FullName,
+				Host:       "github.com",
+			}
+		}
+	}
+
+	for _, l := range legacy {
+		target, ok := targets[l.Key]
+		if !ok {
+			fmt.Printf("  WARN: could not resolve %s; keeping legacy key\n", l.FullName)
+			continue
+		}
+		if err := db.RekeyRepo(ctx, l.Key, target); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("  Migrated %d/%d\n", len(targets), len(legacy))
+	return nil
 }
 
-func fetchAndCache(ctx context.Context, gh *github.Client, listID string, strategy github.Strategy, cached []models.Repo) ([]models.Repo, error) {
+func hostOrDefault(host string) string {
+	if host == "" {
+		return "github.com"
+	}
+	return host
+}
+
+// changed reports whether two repo lists differ in membership or order.
+// Repos are compared by record key, so a rename alone is no change.
+func changed(before, after []models.Repo) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for i := range before {
+		if surrealdb.RepoKey(before[i]) != surrealdb.RepoKey(after[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func fetchAndCache(ctx context.Context, gh *github.Client, ref, listID string, strategy github.Strategy, cached []models.Repo, persist bool) ([]models.Repo, error) {
 	repos, err := strategy.Fetch(ctx, gh, listID, cached)
 	if err != nil {
-		return nil, fmt.Errorf("fetching star list: %w", err)
+		return nil, fmt.Errorf("fetching star list %s: %w", ref, err)
 	}
 	fmt.Printf("Fetched %d repos\n", len(repos))
 
-	if err := writeCache(repos); err != nil {
-		fmt.Printf("  WARN: could not cache to %s: %v\n", cacheFile, err)
+	if !persist {
+		return repos, nil
+	}
+	if err := writeCache(ref, repos); err != nil {
+		fmt.Printf("  WARN: could not cache to %s: %v\n", cacheFile(ref), err)
 	} else {
-		fmt.Printf("Cached to %s\n", cacheFile)
+		fmt.Printf("Cached to %s\n", cacheFile(ref))
 	}
 	return repos, nil
 }
 
-func readCache() ([]models.Repo, error) {
-	data, err := os.ReadFile(cacheFile)
+func readCache(listRef string) ([]models.Repo, error) {
+	data, err := os.ReadFile(cacheFile(listRef))
 	if err != nil {
 		return nil, err
 	}
@@ -218,10 +1047,10 @@ func readCache() ([]models.Repo, error) {
 	return repos, nil
 }
 
-func writeCache(repos []models.Repo) error {
+func writeCache(listRef string, repos []models.Repo) error {
 	data, err := json.MarshalIndent(repos, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(cacheFile, data, 0o644)
+	return os.WriteFile(cacheFile(listRef), data, 0o644)
 }
@@ -0,0 +1,229 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kevinmichaelchen/star-watch/internal/config"
+	"github.com/kevinmichaelchen/star-watch/internal/cost"
+	"github.com/kevinmichaelchen/star-watch/internal/llm"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestSelectStale(t *testing.T) {
+	client := llm.NewClient("http://localhost", "key", "model-a")
+	base := models.Repo{
+		FullName:      "acme/foo",
+		Description:   ptr("Does foo."),
+		ReadmeExcerpt: ptr("# Foo\n\nFoo does things."),
+		AISummary:     ptr("Foo does things."),
+	}
+	// enriched returns base with its summary recorded against the current
+	// inputs, after applying edit.
+	enriched := func(edit func(r *models.Repo)) models.Repo {
+		r := base
+		r.EnrichHash = ptr(client.InputHash(r))
+		r.EnrichModel = ptr(client.Model())
+		r.EnrichPrompt = ptr(llm.PromptVersion)
+		if edit != nil {
+			edit(&r)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name         string
+		repo         models.Repo
+		wantStale    bool
+		wantBaseline bool
+	}{
+		{
+			name: "up to date",
+			repo: enriched(nil),
+		},
+		{
+			name:      "never enriched",
+			repo:      models.Repo{FullName: "acme/new"},
+			wantStale: true,
+		},
+		{
+			name:         "summary without input hash",
+			repo:         base,
+			wantBaseline: true,
+		},
+		{
+			name:      "description changed",
+			repo:      enriched(func(r *models.Repo) { r.Description = ptr("Does foo and bar.") }),
+			wantStale: true,
+		},
+		{
+			name:      "README changed",
+			repo:      enriched(func(r *models.Repo) { r.ReadmeExcerpt = ptr("# Foo\n\nFoo does more things.") }),
+			wantStale: true,
+		},
+		{
+			name:      "README removed",
+			repo:      enriched(func(r *models.Repo) { r.ReadmeExcerpt = nil }),
+			wantStale: true,
+		},
+		{
+			name:      "model changed",
+			repo:      enriched(func(r *models.Repo) { r.EnrichModel = ptr("model-b") }),
+			wantStale: true,
+		},
+		{
+			name:      "model not recorded",
+			repo:      enriched(func(r *models.Repo) { r.EnrichModel = nil }),
+			wantStale: true,
+		},
+		{
+			name:      "prompt changed",
+			repo:      enriched(func(r *models.Repo) { r.EnrichPrompt = ptr("0") }),
+			wantStale: true,
+		},
+		{
+			name: "stars changed",
+			repo: enriched(func(r *models.Repo) { r.Stars = 42 }),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stale, baseline := selectStale([]models.Repo{tt.repo}, client)
+			if got := len(stale) == 1; got != tt.wantStale {
+				t.Errorf("stale = %v, want %v", got, tt.wantStale)
+			}
+			if got := len(baseline) == 1; got != tt.wantBaseline {
+				t.Fatalf("baseline = %v, want %v", got, tt.wantBaseline)
+			}
+			if tt.wantBaseline {
+				r := baseline[0]
+				if r.EnrichHash == nil || *r.EnrichHash != client.InputHash(tt.repo) {
+					t.Errorf("baseline hash = %v, want the current input hash", r.EnrichHash)
+				}
+				if r.EnrichModel == nil || *r.EnrichModel != client.Model() || r.EnrichPrompt == nil || *r.EnrichPrompt != llm.PromptVersion {
+					t.Errorf("baseline model/prompt = %v/%v, want %s/%s", r.EnrichModel, r.EnrichPrompt, client.Model(), llm.PromptVersion)
+				}
+			}
+		})
+	}
+}
+
+func TestNewBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		maxCost float64
+		models  []string
+		wantErr string
+	}{
+		{
+			name:   "no limit allows unpriced models",
+			models: []string{"unpriced-model"},
+		},
+		{
+			name:   "cost limit with priced models",
+			cfg:    config.Config{MaxCost: 1},
+			models: []string{"gpt-4o-mini", "text-embedding-3-small"},
+		},
+		{
+			name:    "cost limit refuses unpriced model",
+			cfg:     config.Config{MaxCost: 1},
+			models:  []string{"gpt-4o-mini", "unpriced-model"},
+			wantErr: "unpriced-model has no price",
+		},
+		{
+			name:    "flag limit refuses unpriced model",
+			maxCost: 1,
+			models:  []string{"unpriced-model"},
+			wantErr: "unpriced-model has no price",
+		},
+		{
+			name:   "MODEL_PRICES prices the model",
+			cfg:    config.Config{MaxCost: 1, ModelPrices: "unpriced-model=1/2"},
+			models: []string{"unpriced-model"},
+		},
+		{
+			name:    "invalid MODEL_PRICES",
+			cfg:     config.Config{ModelPrices: "bogus"},
+			wantErr: "MODEL_PRICES",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newBudget(&tt.cfg, tt.maxCost, 0, tt.models...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("newBudget() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newBudget() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDispatchWithinStopsAtBudget(t *testing.T) {
+	repos := make([]models.Repo, 10)
+	for i := range repos {
+		repos[i].FullName = fmt.Sprintf("acme/repo%d", i)
+	}
+	tests := []struct {
+		name           string
+		maxTokens      int
+		wantDispatched int
+		wantErr        error
+	}{
+		{name: "no limit", wantDispatched: 10},
+		{name: "limit not reached", maxTokens: 1000, wantDispatched: 10},
+		{name: "limit reached", maxTokens: 30, wantDispatched: 3, wantErr: cost.ErrBudgetExceeded},
+		{name: "limit reached mid-repo", maxTokens: 25, wantDispatched: 3, wantErr: cost.ErrBudgetExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := cost.NewBudget(nil, 0, tt.maxTokens)
+			var worked atomic.Int64
+			// One worker, so each repo's usage is recorded before the
+			// next budget check.
+			dispatched, err := dispatchWithin(context.Background(), budget, 1, repos, func(ctx context.Context, repo models.Repo) {
+				budget.Record("chat", 10, 0)
+				worked.Add(1)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("dispatchWithin() error = %v, want %v", err, tt.wantErr)
+			}
+			if dispatched != tt.wantDispatched || int(worked.Load()) != tt.wantDispatched {
+				t.Errorf("dispatched %d, worked %d, want %d", dispatched, worked.Load(), tt.wantDispatched)
+			}
+		})
+	}
+}
+
+func TestSplitListRef(t *testing.T) {
+	sources := map[string]config.GitHubSource{"": {}, "ghe": {}}
+	tests := []struct {
+		ref        string
+		wantSource string
+		wantList   string
+	}{
+		{"UL_a", "", "UL_a"},
+		{"ghe:UL_b", "ghe", "UL_b"},
+		{"ghe:Go: web", "ghe", "Go: web"},
+		{"Go: web", "", "Go: web"},
+		{":ghe: tools", "", "ghe: tools"},
+		{"starred", "", "starred"},
+	}
+	for _, tt := range tests {
+		source, list := splitListRef(tt.ref, sources)
+		if source != tt.wantSource || list != tt.wantList {
+			t.Errorf("splitListRef(%q) = %q, %q, want %q, %q", tt.ref, source, list, tt.wantSource, tt.wantList)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kevinmichaelchen/star-watch/internal/config"
+	"github.com/kevinmichaelchen/star-watch/internal/cost"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+)
+
+// Stage names, in the order a full sync runs them.
+const (
+	StageFetch    = "fetch"
+	StageUpsert   = "upsert"
+	StageReleases = "releases"
+	StageEnrich   = "enrich"
+	StageEmbed    = "embed"
+)
+
+// stage is one step of a sync. Dependencies come in two kinds: after only
+// orders stages that run together, while needs marks stages whose in-memory
+// results this one consumes, so it can't run without them. Stages without
+// needs work against whatever is already in SurrealDB. estimate replaces
+// run in a dry run; it may read but must not write.
+type stage struct {
+	name     string
+	after    []string
+	needs    []string
+	run      func(ctx context.Context, s *syncState) error
+	estimate func(ctx context.Context, s *syncState) error
+}
+
+// syncState is what stages of one run share.
+type syncState struct {
+	cfg  *config.Config
+	opts Options
+	db   *surrealdb.Client
+	ghs  *githubClients
+
+	runID string // sync_run record key, for per-repo checkpoints
+
+	planned map[string]bool // stages in this run
+	budget  *cost.Budget    // spend limits; nil in dry runs
+	est     *estimate       // set in dry runs
+
+	// Set by fetch.
+	listIDs []string
+	members map[string][]models.Repo // list ref → repos, in list order
+	repos   []models.Repo            // all lists, deduplicated
+}
+
+var stages = []*stage{
+	{name: StageFetch, run: fetchStage, estimate: estimateFetch},
+	{name: StageUpsert, needs: []string{StageFetch}, run: upsertStage, estimate: estimateUpsert},
+	{name: StageReleases, after: []string{StageUpsert}, needs: []string{StageFetch}, run: releasesStage, estimate: estimateReleases},
+	{name: StageEnrich, after: []string{StageUpsert}, run: enrichStage, estimate: estimateEnrich},
+	{name: StageEmbed, after: []string{StageEnrich}, run: embedStage, estimate: estimateEmbed},
+}
+
+// StageNames lists the sync stages in execution order.
+func StageNames() []string {
+	names := make([]string, len(stages))
+	for i, st := range stages {
+		names[i] = st.name
+	}
+	return names
+}
+
+func stageByName(name string) *stage {
+	for _, st := range stages {
+		if st.name == name {
+			return st
+		}
+	}
+	return nil
+}
+
+// planStages picks the stages to run and orders them by their dependencies.
+// With only set, exactly those stages run and a missing need is an error.
+// Otherwise every stage not in skip runs, and stages whose needs were
+// skipped are dropped too.
+func planStages(only, skip []string) ([]*stage, error) {
+	for _, name := range slices.Concat(only, skip) {
+		if stageByName(name) == nil {
+			return nil, fmt.Errorf("unknown stage %q (stages: %s)", name, strings.Join(StageNames(), ", "))
+		}
+	}
+
+	selected := map[string]bool{}
+	for _, st := range stages {
+		if len(only) > 0 {
+			selected[st.name] = slices.Contains(only, st.name)
+		} else {
+			selected[st.name] = !slices.Contains(skip, st.name)
+		}
+	}
+
+	ordered, err := sortStages()
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []*stage
+	for _, st := range ordered {
+		if !selected[st.name] {
+			continue
+		}
+		missing := ""
+		for _, need := range st.needs {
+			if !selected[need] {
+				missing = need
+				break
+			}
+		}
+		if missing != "" {
+			if len(only) > 0 {
+				return nil, fmt.Errorf("stage %q needs %q in the same run; add it to --only", st.name, missing)
+			}
+			fmt.Printf("Skipping %s (needs %s)\n", st.name, missing)
+			selected[st.name] = false
+			continue
+		}
+		plan = append(plan, st)
+	}
+	return plan, nil
+}
+
+// pendingStages drops stages an interrupted run already completed, keeping
+// any that a remaining stage needs in the same run.
+func pendingStages(plan []*stage, done []string) []*stage {
+	keep := map[string]bool{}
+	for i := len(plan) - 1; i >= 0; i-- {
+		st := plan[i]
+		if keep[st.name] || !slices.Contains(done, st.name) {
+			keep[st.name] = true
+			for _, need := range st.needs {
+				keep[need] = true
+			}
+		}
+	}
+	var out []*stage
+	for _, st := range plan {
+		if keep[st.name] {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// sortStages orders all stages so each comes after its dependencies,
+// keeping declaration order otherwise.
+func sortStages() ([]*stage, error) {
+	var out []*stage
+	state := map[string]int{} // 0 unvisited, 1 visiting, 2 done
+	var visit func(st *stage) error
+	visit = func(st *stage) error {
+		switch state[st.name] {
+		case 1:
+			return fmt.Errorf("stage dependency cycle at %q", st.name)
+		case 2:
+			return nil
+		}
+		state[st.name] = 1
+		for _, dep := range slices.Concat(st.needs, st.after) {
+			d := stageByName(dep)
+			if d == nil {
+				return fmt.Errorf("stage %q depends on unknown stage %q", st.name, dep)
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[st.name] = 2
+		out = append(out, st)
+		return nil
+	}
+	for _, st := range stages {
+		if err := visit(st); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func stageNames(plan []*stage) []string {
+	names := make([]string, len(plan))
+	for i, st := range plan {
+		names[i] = st.name
+	}
+	return names
+}
+
+func TestPlanStages(t *testing.T) {
+	tests := []struct {
+		name    string
+		only    []string
+		skip    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "full sync",
+			want: []string{"fetch", "upsert", "releases", "enrich", "embed"},
+		},
+		{
+			name: "skip releases",
+			skip: []string{"releases"},
+			want: []string{"fetch", "upsert", "enrich", "embed"},
+		},
+		{
+			name: "skip fetch drops its dependents",
+			skip: []string{"fetch"},
+			want: []string{"enrich", "embed"},
+		},
+		{
+			name: "only reorders",
+			only: []string{"embed", "enrich"},
+			want: []string{"enrich", "embed"},
+		},
+		{
+			name: "only with needs",
+			only: []string{"upsert", "fetch"},
+			want: []string{"fetch", "upsert"},
+		},
+		{
+			name:    "only missing a need",
+			only:    []string{"upsert"},
+			wantErr: `stage "upsert" needs "fetch"`,
+		},
+		{
+			name:    "unknown stage",
+			skip:    []string{"bogus"},
+			wantErr: `unknown stage "bogus"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planStages(tt.only, tt.skip)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("planStages() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("planStages() error = %v", err)
+			}
+			if got := stageNames(plan); !slices.Equal(got, tt.want) {
+				t.Errorf("planStages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingStages(t *testing.T) {
+	full, err := planStages(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		done []string
+		want []string
+	}{
+		{
+			name: "nothing done",
+			want: []string{"fetch", "upsert", "releases", "enrich", "embed"},
+		},
+		{
+			name: "fetch rerun for releases",
+			done: []string{"fetch", "upsert"},
+			want: []string{"fetch", "releases", "enrich", "embed"},
+		},
+		{
+			name: "fetch not needed",
+			done: []string{"fetch", "upsert", "releases"},
+			want: []string{"enrich", "embed"},
+		},
+		{
+			name: "all done",
+			done: []string{"fetch", "upsert", "releases", "enrich", "embed"},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stageNames(pendingStages(full, tt.done))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pendingStages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package readme turns raw README markdown into a compact prose excerpt
+// suitable for LLM prompts.
+package readme
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	fencedCode  = regexp.MustCompile("(?ms)^[ \t]*(```|~~~).*?^[ \t]*(```|~~~)[ \t]*$")
+	linkedImage = regexp.MustCompile(`\[!\[[^\]]*\]\([^)]*\)\]\([^)]*\)`) // badges: [![alt](img)](link)
+	image       = regexp.MustCompile(`!\[[^\]]*\](\([^)]*\)|\[[^\]]*\])`)
+	htmlTag     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	inlineLink  = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	refLink     = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	linkDef     = regexp.MustCompile(`(?m)^[ \t]*\[[^\]]+\]:[ \t]*\S+.*$`)
+	trailingWS  = regexp.MustCompile(`(?m)[ \t]+$`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	heading     = regexp.MustCompile(`^#{1,6}[ \t]+(.*?)[ \t#]*$`)
+	underline   = regexp.MustCompile(`^[ \t]{0,3}(=+|-+)[ \t]*$`) // setext heading underline
+)
+
+// skippedSections are headings (as normalizeHeading returns them) whose
+// whole section is noise for a summary: navigation, install steps and
+// project boilerplate.
+var skippedSections = map[string]bool{
+	"table of contents": true,
+	"contents":          true,
+	"toc":               true,
+	"install":           true,
+	"installation":      true,
+	"license":           true,
+	"contributing":      true,
+	"contributors":      true,
+	"sponsors":          true,
+	"star history":      true,
+	"acknowledgements":  true,
+	"acknowledgments":   true,
+}
+
+// Clean strips markup that carries no meaning for a summary: HTML comments
+// and tags, badges and images, fenced code blocks, link reference
+// definitions and boilerplate sections. Link syntax collapses to its text.
+func Clean(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = htmlComment.ReplaceAllString(text, "")
+	text = fencedCode.ReplaceAllString(text, "")
+	text = linkedImage.ReplaceAllString(text, "")
+	text = image.ReplaceAllString(text, "")
+	text = htmlTag.ReplaceAllString(text, "")
+	text = inlineLink.ReplaceAllString(text, "$1")
+	text = refLink.ReplaceAllString(text, "$1")
+	text = linkDef.ReplaceAllString(text, "")
+
+	var kept []string
+	for _, sec := range sections(text) {
+		if title, ok := sectionTitle(sec); ok && skippedSections[normalizeHeading(title)] {
+			continue
+		}
+		kept = append(kept, sec)
+	}
+	text = strings.Join(kept, "")
+
+	text = trailingWS.ReplaceAllString(text, "")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// Excerpt cleans text and truncates it to at most maxLen bytes. It prefers
+// to cut between sections, then between paragraphs, and only as a last
+// resort mid-paragraph — always on a rune boundary.
+func Excerpt(text string, maxLen int) string {
+	return Truncate(Clean(text), maxLen)
+}
+
+// Truncate shortens text to at most maxLen bytes at the latest section
+// boundary, falling back to paragraph and then rune boundaries when that
+// would keep less than half the budget.
+func Truncate(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	// A lone title section isn't worth keeping over a paragraph-level cut.
+	if out := joinWithin(sections(text), maxLen); len(out) >= maxLen/2 {
+		return out
+	}
+	if out := joinWithin(strings.SplitAfter(text, "\n\n"), maxLen); out != "" {
+		return out
+	}
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return strings.TrimSpace(text[:cut])
+}
+
+// joinWithin concatenates leading parts while the total stays within maxLen.
+func joinWithin(parts []string, maxLen int) string {
+	var b strings.Builder
+	for _, p := range parts {
+		if b.Len()+len(p) > maxLen {
+			break
+		}
+		b.WriteString(p)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// sections splits markdown before each ATX or setext heading, keeping the
+// separators so the parts concatenate back to the input.
+func sections(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	var out []string
+	start, pos := 0, 0
+	for i, line := range lines {
+		if pos > start && isHeading(lines, i) {
+			out = append(out, text[start:pos])
+			start = pos
+		}
+		pos += len(line)
+	}
+	if start < len(text) {
+		out = append(out, text[start:])
+	}
+	return out
+}
+
+// isHeading reports whether lines[i] starts a heading: an ATX "# Title"
+// line, or a setext title line underlined with === or ---. A setext title
+// must follow a blank line, so a --- under the last line of a longer
+// paragraph isn't mistaken for one.
+func isHeading(lines []string, i int) bool {
+	line := strings.TrimRight(lines[i], "\n")
+	if heading.MatchString(line) {
+		return true
+	}
+	if strings.TrimSpace(line) == "" || underline.MatchString(line) || i+1 >= len(lines) {
+		return false
+	}
+	if i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+		return false
+	}
+	return underline.MatchString(strings.TrimRight(lines[i+1], "\n"))
+}
+
+// sectionTitle returns the heading text of a section, if it starts with one.
+func sectionTitle(sec string) (string, bool) {
+	lines := strings.SplitAfter(sec, "\n")
+	if !isHeading(lines, 0) {
+		return "", false
+	}
+	line := strings.TrimRight(lines[0], "\n")
+	if m := heading.FindStringSubmatch(line); m != nil {
+		return strings.TrimSpace(m[1]), true
+	}
+	return strings.TrimSpace(line), true
+}
+
+// normalizeHeading reduces a heading to lowercase words, dropping emoji,
+// punctuation and markup, so "## 📦 Install:" matches "install".
+func normalizeHeading(title string) string {
+	f := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(f, " ")
+}
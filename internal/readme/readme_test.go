@@ -0,0 +1,104 @@
+package readme
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "badges, images and comments",
+			in:   "# Tool\n\n[![CI](https://ci/badge.svg)](https://ci) ![logo](logo.png)\n<!-- hidden -->\nDoes things.",
+			want: "# Tool\n\nDoes things.",
+		},
+		{
+			name: "links collapse to text",
+			in:   "See [the docs](https://example.com) and [guide][1].\n\n[1]: https://example.com/guide",
+			want: "See the docs and guide.",
+		},
+		{
+			name: "fenced code and html",
+			in:   "Intro\n\n```sh\nmake install\n```\n\n<p align=\"center\">Centered</p>",
+			want: "Intro\n\nCentered",
+		},
+		{
+			name: "atx section skipped",
+			in:   "# Tool\n\nAbout.\n\n## Installation\n\nRun it.\n\n## Usage\n\nUse it.",
+			want: "# Tool\n\nAbout.\n\n## Usage\n\nUse it.",
+		},
+		{
+			name: "emoji and punctuation in heading",
+			in:   "# Tool\n\nAbout.\n\n## 📦 Install:\n\nRun it.\n\n## ✨ Features\n\nFast.",
+			want: "# Tool\n\nAbout.\n\n## ✨ Features\n\nFast.",
+		},
+		{
+			name: "setext section skipped",
+			in:   "Tool\n====\n\nAbout.\n\nInstall\n-------\n\nRun it.\n\nUsage\n-----\n\nUse it.",
+			want: "Tool\n====\n\nAbout.\n\nUsage\n-----\n\nUse it.",
+		},
+		{
+			name: "paragraph above a rule is not a heading",
+			in:   "About.\nLicense\n---\n\nMore.",
+			want: "About.\nLicense\n---\n\nMore.",
+		},
+		{
+			name: "only boilerplate",
+			in:   "## License\n\nMIT",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Clean(tt.in); got != tt.want {
+				t.Errorf("Clean() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		maxLen int
+		want   string
+	}{
+		{
+			name:   "fits",
+			in:     "Short.",
+			maxLen: 10,
+			want:   "Short.",
+		},
+		{
+			name:   "section boundary",
+			in:     "# A\n\nFirst part.\n\n# B\n\nSecond part.",
+			maxLen: 30,
+			want:   "# A\n\nFirst part.",
+		},
+		{
+			name:   "paragraph boundary",
+			in:     "First paragraph here.\n\nSecond paragraph here.",
+			maxLen: 30,
+			want:   "First paragraph here.",
+		},
+		{
+			name:   "rune boundary",
+			in:     "héllo wörld",
+			maxLen: 2,
+			want:   "h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.in, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("Truncate() = %q, want %q", got, tt.want)
+			}
+			if len(got) > tt.maxLen {
+				t.Errorf("Truncate() returned %d bytes, over %d", len(got), tt.maxLen)
+			}
+		})
+	}
+}
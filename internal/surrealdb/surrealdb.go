@@ -2,6 +2,7 @@ package surrealdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/kevinmichaelchen/star-watch/internal/config"
 	"github.com/kevinmichaelchen/star-watch/internal/models"
 	sdk "github.com/surrealdb/surrealdb.go"
+	sdkmodels "github.com/surrealdb/surrealdb.go/pkg/models"
 )
 
 // SearchOptions controls what VectorSearch returns.
@@ -16,6 +18,10 @@ type SearchOptions struct {
 	K      int
 	Fields []string   // which columns to SELECT (score is always computed)
 	Sort   []SortSpec // ORDER BY clauses; default: score desc
+	List   string     // restrict to repos in this star list (ID or name)
+	Source string     // restrict to repos imported from this source, among others
+
+	IncludeRemoved bool // include tombstoned repos (removed_at set)
 }
 
 // SortSpec is a single ORDER BY clause.
@@ -28,21 +34,39 @@ type SortSpec struct {
 // Every key must match a SurrealDB field name on the repo table (or the
 // computed "score" alias).
 var allowedFields = map[string]bool{
-	"owner":          true,
-	"name":           true,
-	"full_name":      true,
-	"description":    true,
-	"url":            true,
-	"homepage_url":   true,
-	"stars":          true,
-	"language":       true,
-	"topics":         true,
-	"readme_excerpt": true,
-	"ai_summary":     true,
-	"ai_categories":  true,
-	"fetched_at":     true,
-	"enriched_at":    true,
-	"score":          true,
+	"node_id":           true,
+	"database_id":       true,
+	"aliases":           true,
+	"owner":             true,
+	"name":              true,
+	"full_name":         true,
+	"description":       true,
+	"url":               true,
+	"homepage_url":      true,
+	"host":              true,
+	"stars":             true,
+	"forks":             true,
+	"open_issues":       true,
+	"archived":          true,
+	"fork":              true,
+	"license":           true,
+	"created_at":        true,
+	"pushed_at":         true,
+	"latest_release":    true,
+	"latest_release_at": true,
+	"language":          true,
+	"topics":            true,
+	"readme_excerpt":    true,
+	"readme_path":       true,
+	"readme_length":     true,
+	"ai_summary":        true,
+	"ai_categories":     true,
+	"fetched_at":        true,
+	"enriched_at":       true,
+	"starred_at":        true,
+	"removed_at":        true,
+	"import_source":     true,
+	"score":             true,
 }
 
 // IsAllowedField reports whether f is a valid search field name.
@@ -86,25 +110,114 @@ func (c *Client) InitSchema(ctx context.Context) error {
 	schema := `
 DEFINE TABLE IF NOT EXISTS repo SCHEMAFULL;
 
+DEFINE FIELD IF NOT EXISTS node_id        ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS database_id    ON TABLE repo TYPE option<int>;
+DEFINE FIELD IF NOT EXISTS aliases        ON TABLE repo TYPE array<string> DEFAULT [];
+
 DEFINE FIELD IF NOT EXISTS owner          ON TABLE repo TYPE string;
 DEFINE FIELD IF NOT EXISTS name           ON TABLE repo TYPE string;
 DEFINE FIELD IF NOT EXISTS full_name      ON TABLE repo TYPE string;
 DEFINE FIELD IF NOT EXISTS description    ON TABLE repo TYPE option<string>;
 DEFINE FIELD IF NOT EXISTS url            ON TABLE repo TYPE string;
 DEFINE FIELD IF NOT EXISTS homepage_url   ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS host           ON TABLE repo TYPE option<string>;
 DEFINE FIELD IF NOT EXISTS stars          ON TABLE repo TYPE int;
+DEFINE FIELD IF NOT EXISTS forks          ON TABLE repo TYPE int DEFAULT 0;
+DEFINE FIELD IF NOT EXISTS open_issues    ON TABLE repo TYPE int DEFAULT 0;
+DEFINE FIELD IF NOT EXISTS archived       ON TABLE repo TYPE bool DEFAULT false;
+DEFINE FIELD IF NOT EXISTS fork           ON TABLE repo TYPE bool DEFAULT false;
+DEFINE FIELD IF NOT EXISTS license        ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS created_at     ON TABLE repo TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS pushed_at      ON TABLE repo TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS latest_release ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS latest_release_at ON TABLE repo TYPE option<datetime>;
 DEFINE FIELD IF NOT EXISTS language       ON TABLE repo TYPE option<string>;
 DEFINE FIELD IF NOT EXISTS topics         ON TABLE repo TYPE array<string>;
 DEFINE FIELD IF NOT EXISTS readme_excerpt ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS readme_path    ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS readme_length  ON TABLE repo TYPE option<int>;
 DEFINE FIELD IF NOT EXISTS ai_summary     ON TABLE repo TYPE option<string>;
 DEFINE FIELD IF NOT EXISTS ai_categories  ON TABLE repo TYPE option<array<string>>;
 DEFINE FIELD IF NOT EXISTS embedding      ON TABLE repo TYPE option<array<float>>;
 DEFINE FIELD IF NOT EXISTS fetched_at     ON TABLE repo TYPE datetime;
 DEFINE FIELD IF NOT EXISTS enriched_at    ON TABLE repo TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS enrich_run     ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS embed_run      ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS enrich_hash    ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS enrich_model   ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS enrich_prompt  ON TABLE repo TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS embedded_at    ON TABLE repo TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS starred_at     ON TABLE repo TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS removed_at     ON TABLE repo TYPE option<datetime>;
+-- import_source used to hold only the first import; it now lists them all.
+DEFINE FIELD OVERWRITE import_source ON TABLE repo TYPE option<string | array<string>>;
+UPDATE repo SET import_source = [import_source] WHERE type::is::string(import_source) RETURN NONE;
+DEFINE FIELD OVERWRITE import_source ON TABLE repo TYPE option<array<string>>;
+DEFINE FIELD IF NOT EXISTS listed_at      ON TABLE repo TYPE option<datetime>;
 
-DEFINE INDEX IF NOT EXISTS idx_full_name ON TABLE repo FIELDS full_name UNIQUE;
+-- Not unique: a cached repo keeps its old name until it is fetched again, so
+-- a new repo that takes over a name can briefly share it with the old one.
+REMOVE INDEX IF EXISTS idx_full_name ON TABLE repo;
+DEFINE INDEX idx_full_name ON TABLE repo FIELDS host, full_name;
 REMOVE INDEX IF EXISTS idx_hnsw_embedding ON TABLE repo;
 DEFINE INDEX idx_hnsw_embedding ON TABLE repo FIELDS embedding HNSW DIMENSION 768 DIST COSINE;
+
+-- Embeddings stored before embedded_at existed are as fresh as their summary.
+UPDATE repo SET embedded_at = enriched_at ?? time::now()
+	WHERE embedding IS NOT NONE AND embedded_at IS NONE RETURN NONE;
+-- Repos synced before listed_at existed were in a star list unless imported.
+UPDATE repo SET listed_at = fetched_at
+	WHERE listed_at IS NONE AND (import_source IS NONE OR array::len(->in_list) > 0) RETURN NONE;
+
+DEFINE TABLE IF NOT EXISTS list SCHEMAFULL;
+
+DEFINE FIELD IF NOT EXISTS name      ON TABLE list TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS synced_at ON TABLE list TYPE datetime;
+
+DEFINE TABLE IF NOT EXISTS in_list TYPE RELATION FROM repo TO list SCHEMAFULL;
+
+DEFINE INDEX IF NOT EXISTS idx_in_list_unique ON TABLE in_list FIELDS in, out UNIQUE;
+
+DEFINE TABLE IF NOT EXISTS repo_snapshot SCHEMAFULL;
+
+DEFINE FIELD IF NOT EXISTS repo        ON TABLE repo_snapshot TYPE record<repo>;
+DEFINE FIELD IF NOT EXISTS stars       ON TABLE repo_snapshot TYPE int;
+DEFINE FIELD IF NOT EXISTS forks       ON TABLE repo_snapshot TYPE int;
+DEFINE FIELD IF NOT EXISTS open_issues ON TABLE repo_snapshot TYPE int;
+DEFINE FIELD IF NOT EXISTS taken_at    ON TABLE repo_snapshot TYPE datetime;
+
+DEFINE INDEX IF NOT EXISTS idx_snapshot_taken_at ON TABLE repo_snapshot FIELDS taken_at;
+
+DEFINE TABLE IF NOT EXISTS release SCHEMAFULL;
+
+DEFINE FIELD IF NOT EXISTS repo          ON TABLE release TYPE record<repo>;
+DEFINE FIELD IF NOT EXISTS tag           ON TABLE release TYPE string;
+DEFINE FIELD IF NOT EXISTS name          ON TABLE release TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS url           ON TABLE release TYPE string;
+DEFINE FIELD IF NOT EXISTS published_at  ON TABLE release TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS prerelease    ON TABLE release TYPE bool DEFAULT false;
+DEFINE FIELD IF NOT EXISTS notes         ON TABLE release TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS ai_summary    ON TABLE release TYPE option<string>;
+DEFINE FIELD IF NOT EXISTS first_seen_at ON TABLE release TYPE datetime DEFAULT time::now();
+
+DEFINE INDEX IF NOT EXISTS idx_release_published_at ON TABLE release FIELDS published_at;
+
+DEFINE TABLE IF NOT EXISTS sync_run SCHEMAFULL;
+
+DEFINE FIELD IF NOT EXISTS started_at  ON TABLE sync_run TYPE datetime;
+DEFINE FIELD IF NOT EXISTS finished_at ON TABLE sync_run TYPE option<datetime>;
+DEFINE FIELD IF NOT EXISTS stages_done ON TABLE sync_run TYPE option<array<string>>;
+DEFINE FIELD IF NOT EXISTS force       ON TABLE sync_run TYPE bool DEFAULT false;
+DEFINE FIELD IF NOT EXISTS only        ON TABLE sync_run TYPE array<string> DEFAULT [];
+DEFINE FIELD IF NOT EXISTS skip        ON TABLE sync_run TYPE array<string> DEFAULT [];
+DEFINE FIELD IF NOT EXISTS list_ids    ON TABLE sync_run TYPE array<string> DEFAULT [];
+
+DEFINE TABLE IF NOT EXISTS list_assignment SCHEMAFULL;
+
+DEFINE FIELD IF NOT EXISTS node_id     ON TABLE list_assignment TYPE string;
+DEFINE FIELD IF NOT EXISTS list_id     ON TABLE list_assignment TYPE string;
+DEFINE FIELD IF NOT EXISTS origin      ON TABLE list_assignment TYPE string;
+DEFINE FIELD IF NOT EXISTS recorded_at ON TABLE list_assignment TYPE datetime DEFAULT time::now();
 `
 	_, err := sdk.Query[any](ctx, c.db, schema, nil)
 	if err != nil {
@@ -113,17 +226,120 @@ DEFINE INDEX idx_hnsw_embedding ON TABLE repo FIELDS embedding HNSW DIMENSION 76
 	return nil
 }
 
+// RepoKey returns the record ID key for a repo: its GitHub node ID, which
+// survives renames and transfers. Repos without one (records that predate
+// node IDs and couldn't be migrated) keep the legacy owner__name key. Repos
+// on hosts other than github.com (GitHub Enterprise) are prefixed with the
+// host so both can share one database.
+func RepoKey(r models.Repo) string {
+	key := r.NodeID
+	if key == "" {
+		key = legacyKey(r.FullName)
+	}
+	if r.Host != "" && r.Host != "github.com" {
+		key = r.Host + "__" + key
+	}
+	return key
+}
+
+func legacyKey(fullName string) string {
+	return strings.ReplaceAll(fullName, "/", "__")
+}
+
+// upsertRepoQuery stores one repo row (see repoRow): the repo itself, its
+// aliases and a history snapshot. aliases accumulates every full_name the
+// record has been synced under, and import_source every import it came
+// from.
+const upsertRepoQuery = `
+	UPSERT type::thing("repo", $row.id) MERGE $row.data;
+	UPDATE type::thing("repo", $row.id) SET aliases = array::union(aliases ?? [], [full_name]),
+		import_source = IF $row.import_source IS NONE THEN import_source
+			ELSE array::union(import_source ?? [], $row.import_source) END;
+	UPSERT type::thing("repo_snapshot", [$row.id, $row.snapshot.taken_at]) MERGE $row.snapshot;`
+
 func (c *Client) UpsertRepo(ctx context.Context, r models.Repo) error {
+	_, err := sdk.Query[any](ctx, c.db, upsertRepoQuery, map[string]any{"row": repoRow(r)})
+	if err != nil {
+		return fmt.Errorf("upserting %s: %w", r.FullName, err)
+	}
+	return nil
+}
+
+// RecordError is a failure to store one record of a batch.
+type RecordError struct {
+	FullName string
+	Err      error
+}
+
+// UpsertRepos stores repos in batches of batchSize, each in one transaction
+// and one round trip. If a statement in a batch fails, its repos are retried
+// one at a time so the rest still land and each failure is reported on its
+// own; any other error is returned. progress,
+// if non-nil, is called after each batch with the number of repos handled.
+func (c *Client) UpsertRepos(ctx context.Context, repos []models.Repo, batchSize int, progress func(done int)) ([]RecordError, error) {
+	if batchSize <= 0 {
+		batchSize = len(repos)
+	}
+	var failed []RecordError
+	for start := 0; start < len(repos); start += batchSize {
+		batch := repos[start:min(start+batchSize, len(repos))]
+		rows := make([]map[string]any, len(batch))
+		for i, r := range batch {
+			rows[i] = repoRow(r)
+		}
+
+		_, err := sdk.Query[any](ctx, c.db,
+			"BEGIN TRANSACTION;\nFOR $row IN $rows {"+upsertRepoQuery+"\n};\nCOMMIT TRANSACTION;",
+			map[string]any{"rows": rows})
+		if err != nil {
+			if ctx.Err() != nil {
+				return failed, ctx.Err()
+			}
+			// Only a statement that failed is worth retrying per record;
+			// anything else (connection, timeout, encoding) would fail the
+			// same way for every repo.
+			if !errors.Is(err, &sdk.QueryError{}) {
+				return failed, fmt.Errorf("upserting repos: %w", err)
+			}
+			for _, r := range batch {
+				if err := c.UpsertRepo(ctx, r); err != nil {
+					failed = append(failed, RecordError{FullName: r.FullName, Err: err})
+				}
+			}
+		}
+		if progress != nil {
+			progress(start + len(batch))
+		}
+	}
+	return failed, nil
+}
+
+// repoRow builds the parameters of upsertRepoQuery for a repo.
+func repoRow(r models.Repo) map[string]any {
 	// Build data map with only non-nil optional fields to avoid
 	// CBOR NULL vs SurrealDB NONE mismatch.
-	id := strings.ReplaceAll(r.FullName, "/", "__")
+	id := RepoKey(r)
+	fetchedAt := time.Now()
+	if r.FetchedAt != nil {
+		fetchedAt = *r.FetchedAt
+	}
 	data := map[string]any{
-		"owner":      r.Owner,
-		"name":       r.Name,
-		"full_name":  r.FullName,
-		"url":        r.URL,
-		"stars":      r.Stars,
-		"fetched_at": time.Now().UTC(),
+		"owner":       r.Owner,
+		"name":        r.Name,
+		"full_name":   r.FullName,
+		"url":         r.URL,
+		"stars":       r.Stars,
+		"forks":       r.Forks,
+		"open_issues": r.OpenIssues,
+		"archived":    r.Archived,
+		"fork":        r.Fork,
+		"fetched_at":  fetchedAt.UTC(),
+	}
+	if r.NodeID != "" {
+		data["node_id"] = r.NodeID
+	}
+	if r.DatabaseID != 0 {
+		data["database_id"] = r.DatabaseID
 	}
 	if r.Description != nil {
 		data["description"] = *r.Description
@@ -131,9 +347,27 @@ func (c *Client) UpsertRepo(ctx context.Context, r models.Repo) error {
 	if r.HomepageURL != nil {
 		data["homepage_url"] = *r.HomepageURL
 	}
+	if r.Host != "" {
+		data["host"] = r.Host
+	}
 	if r.Language != nil {
 		data["language"] = *r.Language
 	}
+	if r.License != nil {
+		data["license"] = *r.License
+	}
+	if r.CreatedAt != nil {
+		data["created_at"] = r.CreatedAt.UTC()
+	}
+	if r.PushedAt != nil {
+		data["pushed_at"] = r.PushedAt.UTC()
+	}
+	if r.LatestRelease != nil {
+		data["latest_release"] = *r.LatestRelease
+	}
+	if r.LatestReleaseAt != nil {
+		data["latest_release_at"] = r.LatestReleaseAt.UTC()
+	}
 	topics := r.Topics
 	if topics == nil {
 		topics = []string{}
@@ -142,24 +376,455 @@ func (c *Client) UpsertRepo(ctx context.Context, r models.Repo) error {
 	if r.ReadmeExcerpt != nil {
 		data["readme_excerpt"] = *r.ReadmeExcerpt
 	}
+	if r.ReadmePath != nil {
+		data["readme_path"] = *r.ReadmePath
+	}
+	if r.ReadmeLength != nil {
+		data["readme_length"] = *r.ReadmeLength
+	}
+	if r.StarredAt != nil {
+		data["starred_at"] = r.StarredAt.UTC()
+	}
+
+	// Record a stars/forks/issues point keyed by (repo, fetch time). Syncs
+	// refresh the counts of cached repos and stamp them with the sync time;
+	// counts that weren't refreshed keep their old key, so they don't add a
+	// duplicate point.
+	snapshot := map[string]any{
+		"repo":        sdkmodels.NewRecordID("repo", id),
+		"stars":       r.Stars,
+		"forks":       r.Forks,
+		"open_issues": r.OpenIssues,
+		"taken_at":    fetchedAt.UTC(),
+	}
+
+	row := map[string]any{
+		"id":       id,
+		"data":     data,
+		"snapshot": snapshot,
+	}
+	if len(r.ImportSource) > 0 {
+		row["import_source"] = r.ImportSource
+	}
+	return row
+}
+
+// UpsertReleases stores a repo's releases in the release table, keyed by
+// (repo, tag). Existing AI summaries are kept.
+func (c *Client) UpsertReleases(ctx context.Context, r models.Repo, releases []models.Release) error {
+	if len(releases) == 0 {
+		return nil
+	}
+	rows := make([]map[string]any, 0, len(releases))
+	for _, rel := range releases {
+		row := map[string]any{
+			"repo":       sdkmodels.NewRecordID("repo", RepoKey(r)),
+			"tag":        rel.Tag,
+			"url":        rel.URL,
+			"prerelease": rel.Prerelease,
+		}
+		if rel.Name != nil {
+			row["name"] = *rel.Name
+		}
+		if rel.PublishedAt != nil {
+			row["published_at"] = rel.PublishedAt.UTC()
+		}
+		if rel.Notes != nil {
+			row["notes"] = *rel.Notes
+		}
+		rows = append(rows, row)
+	}
 
 	_, err := sdk.Query[any](ctx, c.db,
-		`UPSERT type::thing("repo", $id) MERGE $data`,
+		`FOR $rel IN $releases {
+			UPSERT type::thing("release", [$id, $rel.tag]) MERGE $rel;
+		};`,
 		map[string]any{
-			"id":   id,
-			"data": data,
+			"id":       RepoKey(r),
+			"releases": rows,
 		})
 	if err != nil {
-		return fmt.Errorf("upserting %s: %w", r.FullName, err)
+		return fmt.Errorf("upserting releases for %s: %w", r.FullName, err)
+	}
+	return nil
+}
+
+// GetReleasesSince returns releases of live repos published at or after
+// since, newest first. Releases whose repo no longer exists are skipped. If list is non-empty, only repos in that star list
+// (ID or name) are included.
+func (c *Client) GetReleasesSince(ctx context.Context, since time.Time, list string) ([]models.Release, error) {
+	query := `SELECT record::id(repo) AS repo_key, repo.full_name AS full_name,
+			tag, name, url, published_at, prerelease, notes, ai_summary
+		FROM release
+		WHERE published_at >= $since AND repo.full_name IS NOT NONE AND repo.removed_at IS NONE`
+	vars := map[string]any{"since": since.UTC()}
+	if list != "" {
+		query += ` AND array::len(repo->in_list->(list WHERE record::id(id) = $list OR name = $list)) > 0`
+		vars["list"] = list
+	}
+	query += ` ORDER BY published_at DESC`
+
+	results, err := sdk.Query[[]models.Release](ctx, c.db, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("querying releases: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// UpdateReleaseSummary stores the one-line AI summary of a release.
+func (c *Client) UpdateReleaseSummary(ctx context.Context, repoKey, tag, summary string) error {
+	_, err := sdk.Query[any](ctx, c.db,
+		`UPDATE type::thing("release", [$repo_key, $tag]) SET ai_summary = $summary`,
+		map[string]any{
+			"repo_key": repoKey,
+			"tag":      tag,
+			"summary":  summary,
+		})
+	if err != nil {
+		return fmt.Errorf("updating summary for release %s: %w", tag, err)
+	}
+	return nil
+}
+
+// SyncRun is one invocation of sync: the options it ran with and the
+// stages it completed, so an interrupted run can be resumed.
+type SyncRun struct {
+	ID         string     `json:"id"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	StagesDone []string   `json:"stages_done"`
+	Force      bool       `json:"force"`
+	Only       []string   `json:"only"`
+	Skip       []string   `json:"skip"`
+	ListIDs    []string   `json:"list_ids"`
+}
+
+// StartSyncRun records the start of a sync run.
+func (c *Client) StartSyncRun(ctx context.Context, run SyncRun) error {
+	_, err := sdk.Query[any](ctx, c.db,
+		`CREATE type::thing("sync_run", $id) SET
+			started_at = time::now(),
+			stages_done = [],
+			force = $force,
+			only = $only,
+			skip = $skip,
+			list_ids = $list_ids`,
+		map[string]any{
+			"id":       run.ID,
+			"force":    run.Force,
+			"only":     nonNil(run.Only),
+			"skip":     nonNil(run.Skip),
+			"list_ids": nonNil(run.ListIDs),
+		})
+	if err != nil {
+		return fmt.Errorf("recording sync run: %w", err)
 	}
 	return nil
 }
 
-func (c *Client) GetUnenrichedRepos(ctx context.Context) ([]models.Repo, error) {
+// MarkStageDone records that a stage of a run completed.
+func (c *Client) MarkStageDone(ctx context.Context, runID, stage string) error {
+	_, err := sdk.Query[any](ctx, c.db,
+		`UPDATE type::thing("sync_run", $id) SET stages_done = array::union(stages_done ?? [], [$stage])`,
+		map[string]any{"id": runID, "stage": stage})
+	if err != nil {
+		return fmt.Errorf("recording stage %s of run %s: %w", stage, runID, err)
+	}
+	return nil
+}
+
+// FinishSyncRun marks a run as complete.
+func (c *Client) FinishSyncRun(ctx context.Context, runID string) error {
+	_, err := sdk.Query[any](ctx, c.db,
+		`UPDATE type::thing("sync_run", $id) SET finished_at = time::now()`,
+		map[string]any{"id": runID})
+	if err != nil {
+		return fmt.Errorf("finishing run %s: %w", runID, err)
+	}
+	return nil
+}
+
+// GetLatestSyncRun returns the most recently started run, or nil if there is
+// none. Runs recorded before resumable syncs are ignored.
+func (c *Client) GetLatestSyncRun(ctx context.Context) (*SyncRun, error) {
+	results, err := sdk.Query[[]SyncRun](ctx, c.db,
+		`SELECT *, record::id(id) AS id FROM sync_run
+		WHERE stages_done IS NOT NONE
+		ORDER BY started_at DESC LIMIT 1`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("querying sync runs: %w", err)
+	}
+	if len(*results) == 0 || len((*results)[0].Result) == 0 {
+		return nil, nil
+	}
+	return &(*results)[0].Result[0], nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// GetPreviousSyncTime returns when the sync before the most recent one
+// started, i.e. the point "since the previous sync" refers to once the
+// latest sync has run. Only runs that fetched from GitHub count. ok is false
+// if fewer than two such syncs were recorded.
+func (c *Client) GetPreviousSyncTime(ctx context.Context) (t time.Time, ok bool, err error) {
+	results, err := sdk.Query[[]time.Time](ctx, c.db,
+		`SELECT VALUE started_at FROM sync_run
+		WHERE stages_done IS NONE OR "fetch" INSIDE stages_done
+		ORDER BY started_at DESC LIMIT 2`, nil)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("querying sync runs: %w", err)
+	}
+	if len(*results) == 0 || len((*results)[0].Result) < 2 {
+		return time.Time{}, false, nil
+	}
+	return (*results)[0].Result[1], true, nil
+}
+
+// Snapshot is one point in a repo's stargazer history.
+type Snapshot struct {
+	RepoKey    string    `json:"repo_key"` // see RepoKey
+	FullName   string    `json:"full_name"`
+	Stars      int       `json:"stars"`
+	Forks      int       `json:"forks"`
+	OpenIssues int       `json:"open_issues"`
+	TakenAt    time.Time `json:"taken_at"`
+}
+
+// GetSnapshots returns history points taken at or after since for live
+// repos, oldest first. Points whose repo no longer exists are skipped. If list is non-empty, only repos in that star list
+// (ID or name) are included.
+func (c *Client) GetSnapshots(ctx context.Context, since time.Time, list string) ([]Snapshot, error) {
+	query := `SELECT record::id(repo) AS repo_key, repo.full_name AS full_name, stars, forks, open_issues, taken_at
+		FROM repo_snapshot
+		WHERE taken_at >= $since AND repo.full_name IS NOT NONE AND repo.removed_at IS NONE`
+	vars := map[string]any{"since": since.UTC()}
+	if list != "" {
+		// listFilter is written against repo; evaluate it on the linked record.
+		query += ` AND array::len(repo->in_list->(list WHERE record::id(id) = $list OR name = $list)) > 0`
+		vars["list"] = list
+	}
+	query += ` ORDER BY taken_at`
+
+	results, err := sdk.Query[[]Snapshot](ctx, c.db, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("querying snapshots: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// SyncListMembership upserts the list record and makes its in_list edges
+// match repos exactly: missing edges are created and edges to repos no
+// longer in the list are deleted. The repos themselves must already exist.
+func (c *Client) SyncListMembership(ctx context.Context, listID, name string, repos []models.Repo) error {
+	ids := make([]string, len(repos))
+	for i, r := range repos {
+		ids[i] = RepoKey(r)
+	}
+	data := map[string]any{"synced_at": time.Now().UTC()}
+	if name != "" {
+		data["name"] = name
+	}
+
+	_, err := sdk.Query[any](ctx, c.db, `
+BEGIN TRANSACTION;
+LET $list = type::thing("list", $list_id);
+UPSERT $list MERGE $data;
+DELETE in_list WHERE out = $list AND record::id(in) NOTINSIDE $repo_ids;
+FOR $id IN $repo_ids {
+	LET $repo = type::thing("repo", $id);
+	IF array::len(SELECT id FROM in_list WHERE in = $repo AND out = $list) = 0 {
+		RELATE $repo->in_list->$list;
+	};
+	UPDATE $repo SET listed_at = listed_at ?? time::now() RETURN NONE;
+};
+COMMIT TRANSACTION;`,
+		map[string]any{
+			"list_id":  listID,
+			"data":     data,
+			"repo_ids": ids,
+		})
+	if err != nil {
+		return fmt.Errorf("syncing membership for list %s: %w", listID, err)
+	}
+	return nil
+}
+
+// DropOtherLists deletes every list except keep (list IDs as passed to
+// SyncListMembership) together with its membership edges, so repos found
+// only in lists that are no longer configured are tombstoned by the next
+// ReconcileRemoved. It returns the labels ("name (id)", or the bare ID)
+// of the dropped lists.
+func (c *Client) DropOtherLists(ctx context.Context, keep []string) ([]string, error) {
+	results, err := sdk.Query[[]map[string]any](ctx, c.db, `
+BEGIN TRANSACTION;
+DELETE in_list WHERE record::id(out) NOTINSIDE $keep;
+SELECT record::id(id) AS id, name FROM list WHERE record::id(id) NOTINSIDE $keep;
+DELETE list WHERE record::id(id) NOTINSIDE $keep;
+COMMIT TRANSACTION;`,
+		map[string]any{"keep": keep})
+	if err != nil {
+		return nil, fmt.Errorf("dropping unconfigured lists: %w", err)
+	}
+	if len(*results) < 2 {
+		return nil, nil
+	}
+	var dropped []string
+	for _, row := range (*results)[1].Result {
+		id, _ := row["id"].(string)
+		name, _ := row["name"].(string)
+		if name == "" {
+			dropped = append(dropped, id)
+		} else {
+			dropped = append(dropped, fmt.Sprintf("%s (%s)", name, id))
+		}
+	}
+	return dropped, nil
+}
+
+// GetCategorizedRepos returns live github.com repos that have a node ID and
+// AI categories, for organizing into star lists.
+func (c *Client) GetCategorizedRepos(ctx context.Context) ([]models.Repo, error) {
+	results, err := sdk.Query[[]models.Repo](ctx, c.db,
+		`SELECT node_id, full_name, host, ai_categories FROM repo
+		WHERE node_id IS NOT NONE
+			AND array::len(ai_categories ?? []) > 0
+			AND removed_at IS NONE
+			AND (host IS NONE OR host = "github.com")
+		ORDER BY full_name`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("querying categorized repos: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// ListAssignment records that a repo has been in a star list, either because
+// organize added it ("organize") or because it was seen there ("observed").
+type ListAssignment struct {
+	NodeID string `json:"node_id"`
+	ListID string `json:"list_id"`
+	Origin string `json:"origin"`
+}
+
+// GetListAssignments returns every recorded (repo, list) pair.
+func (c *Client) GetListAssignments(ctx context.Context) ([]ListAssignment, error) {
+	results, err := sdk.Query[[]ListAssignment](ctx, c.db,
+		`SELECT node_id, list_id, origin FROM list_assignment`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("querying list assignments: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// RecordListAssignments stores (repo, list) pairs. Existing pairs keep their
+// original origin and timestamp.
+func (c *Client) RecordListAssignments(ctx context.Context, assignments []ListAssignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	_, err := sdk.Query[any](ctx, c.db, `
+FOR $a IN $assignments {
+	LET $id = type::thing("list_assignment", [$a.node_id, $a.list_id]);
+	IF (SELECT VALUE id FROM ONLY $id) IS NONE {
+		CREATE $id CONTENT $a;
+	};
+};`,
+		map[string]any{"assignments": assignments})
+	if err != nil {
+		return fmt.Errorf("recording list assignments: %w", err)
+	}
+	return nil
+}
+
+// ReconcileRemoved tombstones repos that no longer belong to any star list
+// by setting removed_at, and clears removed_at on repos that reappeared.
+// Repos that only ever came from imports (never in a star list, so
+// listed_at is unset) are not tombstoned.
+// Call it after SyncListMembership. It returns the number of repos newly
+// marked as removed.
+func (c *Client) ReconcileRemoved(ctx context.Context) (int, error) {
+	results, err := sdk.Query[[]map[string]any](ctx, c.db, `
+UPDATE repo SET removed_at = NONE
+	WHERE removed_at IS NOT NONE AND array::len(->in_list) > 0 RETURN NONE;
+UPDATE repo SET removed_at = time::now()
+	WHERE removed_at IS NONE AND array::len(->in_list) = 0 AND listed_at IS NOT NONE RETURN id;`,
+		nil)
+	if err != nil {
+		return 0, fmt.Errorf("reconciling removed repos: %w", err)
+	}
+	if len(*results) < 2 {
+		return 0, nil
+	}
+	return len((*results)[1].Result), nil
+}
+
+// PruneRemoved permanently deletes tombstoned repos with their graph edges,
+// star history and releases, along with rows left behind by earlier prunes.
+// It returns the full names of the deleted repos.
+func (c *Client) PruneRemoved(ctx context.Context) ([]string, error) {
+	results, err := sdk.Query[[]models.Repo](ctx, c.db, `
+BEGIN TRANSACTION;
+DELETE repo_snapshot WHERE repo.removed_at IS NOT NONE OR repo.full_name IS NONE;
+DELETE release WHERE repo.removed_at IS NOT NONE OR repo.full_name IS NONE;
+DELETE repo WHERE removed_at IS NOT NONE RETURN BEFORE;
+COMMIT TRANSACTION;`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("pruning removed repos: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	// The deleted repos are the result of the last statement.
+	deleted := (*results)[len(*results)-1].Result
+	names := make([]string, 0, len(deleted))
+	for _, r := range deleted {
+		names = append(names, r.FullName)
+	}
+	return names, nil
+}
+
+// listFilter is a WHERE clause matching repos in the star list bound to
+// $list, which may be either the list's node ID or its name.
+const listFilter = `array::len(->in_list->(list WHERE record::id(id) = $list OR name = $list)) > 0`
+
+// GetReposToReenrich returns live repos not yet enriched by the given run,
+// for forced re-enrichment that can resume after an interruption.
+func (c *Client) GetReposToReenrich(ctx context.Context, runID string) ([]models.Repo, error) {
+	results, err := sdk.Query[[]models.Repo](ctx, c.db,
+		`SELECT * FROM repo WHERE removed_at IS NONE AND (enrich_run IS NONE OR enrich_run != $run)`,
+		map[string]any{"run": runID})
+	if err != nil {
+		return nil, fmt.Errorf("querying repos to re-enrich: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// GetReposToReembed is GetReposToReenrich for embeddings.
+func (c *Client) GetReposToReembed(ctx context.Context, runID string) ([]models.Repo, error) {
 	results, err := sdk.Query[[]models.Repo](ctx, c.db,
-		`SELECT * FROM repo WHERE ai_summary IS NONE`, nil)
+		`SELECT * FROM repo WHERE removed_at IS NONE AND (embed_run IS NONE OR embed_run != $run)`,
+		map[string]any{"run": runID})
 	if err != nil {
-		return nil, fmt.Errorf("querying unenriched repos: %w", err)
+		return nil, fmt.Errorf("querying repos to re-embed: %w", err)
 	}
 	if len(*results) == 0 {
 		return nil, nil
@@ -167,9 +832,10 @@ func (c *Client) GetUnenrichedRepos(ctx context.Context) ([]models.Repo, error)
 	return (*results)[0].Result, nil
 }
 
+// GetAllRepos returns every repo that has not been removed from its lists.
 func (c *Client) GetAllRepos(ctx context.Context) ([]models.Repo, error) {
 	results, err := sdk.Query[[]models.Repo](ctx, c.db,
-		`SELECT * FROM repo`, nil)
+		`SELECT * FROM repo WHERE removed_at IS NONE`, nil)
 	if err != nil {
 		return nil, fmt.Errorf("querying all repos: %w", err)
 	}
@@ -179,9 +845,27 @@ func (c *Client) GetAllRepos(ctx context.Context) ([]models.Repo, error) {
 	return (*results)[0].Result, nil
 }
 
+// GetEnrichmentCandidates returns live repos without their embeddings, for
+// comparing enrichment input hashes.
+func (c *Client) GetEnrichmentCandidates(ctx context.Context) ([]models.Repo, error) {
+	results, err := sdk.Query[[]models.Repo](ctx, c.db,
+		`SELECT * OMIT embedding FROM repo WHERE removed_at IS NONE`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("querying enrichment candidates: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// GetReposNeedingEmbedding returns enriched repos with no embedding or one
+// older than their summary.
 func (c *Client) GetReposNeedingEmbedding(ctx context.Context) ([]models.Repo, error) {
 	results, err := sdk.Query[[]models.Repo](ctx, c.db,
-		`SELECT * FROM repo WHERE ai_summary IS NOT NONE AND embedding IS NONE`, nil)
+		`SELECT * FROM repo
+		WHERE ai_summary IS NOT NONE AND removed_at IS NONE
+			AND (embedding IS NONE OR embedded_at < enriched_at)`, nil)
 	if err != nil {
 		return nil, fmt.Errorf("querying repos needing embedding: %w", err)
 	}
@@ -191,36 +875,190 @@ func (c *Client) GetReposNeedingEmbedding(ctx context.Context) ([]models.Repo, e
 	return (*results)[0].Result, nil
 }
 
-func (c *Client) UpdateEnrichment(ctx context.Context, fullName string, summary string, categories []string) error {
+// Enrichment is an LLM summary with the fingerprint of its input.
+type Enrichment struct {
+	Summary       string
+	Categories    []string
+	InputHash     string
+	Model         string
+	PromptVersion string
+}
+
+// UpdateEnrichment stores a repo's AI summary and categories. A non-empty
+// runID marks the repo as enriched by that sync run.
+func (c *Client) UpdateEnrichment(ctx context.Context, r models.Repo, runID string, e Enrichment) error {
+	categories := e.Categories
 	if categories == nil {
 		categories = []string{}
 	}
-	_, err := sdk.Query[any](ctx, c.db,
-		`UPDATE repo SET
+	query := `UPDATE type::thing("repo", $id) SET
 			ai_summary = $ai_summary,
 			ai_categories = $ai_categories,
-			enriched_at = time::now()
-		WHERE full_name = $full_name`,
+			enrich_hash = $hash,
+			enrich_model = $model,
+			enrich_prompt = $prompt,
+			enriched_at = time::now()`
+	if runID != "" {
+		query += `, enrich_run = $run`
+	}
+	_, err := sdk.Query[any](ctx, c.db, query,
 		map[string]any{
-			"full_name":     fullName,
-			"ai_summary":    summary,
+			"id":            RepoKey(r),
+			"run":           runID,
+			"ai_summary":    e.Summary,
 			"ai_categories": categories,
+			"hash":          e.InputHash,
+			"model":         e.Model,
+			"prompt":        e.PromptVersion,
 		})
 	if err != nil {
-		return fmt.Errorf("updating enrichment for %s: %w", fullName, err)
+		return fmt.Errorf("updating enrichment for %s: %w", r.FullName, err)
 	}
 	return nil
 }
 
-func (c *Client) UpdateEmbedding(ctx context.Context, fullName string, embedding []float32) error {
-	_, err := sdk.Query[any](ctx, c.db,
-		`UPDATE repo SET embedding = $embedding WHERE full_name = $full_name`,
+// UpdateEmbeddings stores the embeddings of repos (vectors[i] belongs to
+// repos[i]) in one transaction. A non-empty runID marks the repos as
+// embedded by that sync run.
+func (c *Client) UpdateEmbeddings(ctx context.Context, repos []models.Repo, runID string, vectors [][]float32) error {
+	rows := make([]map[string]any, len(repos))
+	for i, r := range repos {
+		rows[i] = map[string]any{"id": RepoKey(r), "embedding": vectors[i]}
+	}
+	set := `embedding = $row.embedding, embedded_at = time::now()`
+	if runID != "" {
+		set += `, embed_run = $run`
+	}
+	_, err := sdk.Query[any](ctx, c.db, `
+BEGIN TRANSACTION;
+FOR $row IN $rows {
+	UPDATE type::thing("repo", $row.id) SET `+set+`;
+};
+COMMIT TRANSACTION;`,
+		map[string]any{"rows": rows, "run": runID})
+	if err != nil {
+		return fmt.Errorf("updating %d embeddings: %w", len(repos), err)
+	}
+	return nil
+}
+
+// RecordEnrichmentInputs stores the EnrichHash, EnrichModel and
+// EnrichPrompt of each repo without touching its summary. It backfills
+// fingerprints for summaries written before they were tracked.
+func (c *Client) RecordEnrichmentInputs(ctx context.Context, repos []models.Repo) error {
+	rows := make([]map[string]any, len(repos))
+	for i, r := range repos {
+		rows[i] = map[string]any{
+			"id":     RepoKey(r),
+			"hash":   r.EnrichHash,
+			"model":  r.EnrichModel,
+			"prompt": r.EnrichPrompt,
+		}
+	}
+	_, err := sdk.Query[any](ctx, c.db, `
+FOR $row IN $rows {
+	UPDATE type::thing("repo", $row.id) SET
+		enrich_hash = $row.hash,
+		enrich_model = $row.model,
+		enrich_prompt = $row.prompt;
+};`,
+		map[string]any{"rows": rows})
+	if err != nil {
+		return fmt.Errorf("recording enrichment inputs: %w", err)
+	}
+	return nil
+}
+
+// LegacyRepo is a repo record still keyed by owner__name.
+type LegacyRepo struct {
+	Key      string `json:"key"`
+	Owner    string `json:"owner"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Host     string `json:"host"`
+}
+
+// GetLegacyRepos returns repo records that have no node ID yet.
+func (c *Client) GetLegacyRepos(ctx context.Context) ([]LegacyRepo, error) {
+	results, err := sdk.Query[[]LegacyRepo](ctx, c.db,
+		`SELECT record::id(id) AS key, owner, name, full_name, host FROM repo WHERE node_id IS NONE`, nil)
+	if err != nil {
+		return nil, fmt.Errorf("querying legacy repos: %w", err)
+	}
+	if len(*results) == 0 {
+		return nil, nil
+	}
+	return (*results)[0].Result, nil
+}
+
+// RekeyRepo moves a legacy record to the node-ID key of target, carrying
+// over its enrichment, embedding, aliases, list membership, snapshots and
+// releases. If a record already exists under the new key (e.g. the repo was
+// synced after a rename), its fields win; a missing summary or embedding is
+// taken from the legacy record together with its hash, model, prompt
+// version and timestamps, so the repo isn't re-enriched or re-embedded.
+func (c *Client) RekeyRepo(ctx context.Context, oldKey string, target models.Repo) error {
+	newKey := RepoKey(target)
+	_, err := sdk.Query[any](ctx, c.db, `
+BEGIN TRANSACTION;
+LET $old = type::thing("repo", $old_key);
+LET $new = type::thing("repo", $new_key);
+LET $data = SELECT * OMIT id FROM ONLY $old;
+LET $lists = SELECT VALUE out FROM in_list WHERE in = $old;
+LET $releases = SELECT * OMIT id FROM release WHERE repo = $old;
+
+-- Deleting the node also removes its in_list edges, which are recreated
+-- below.
+DELETE $old;
+
+IF (SELECT VALUE id FROM ONLY $new) IS NONE {
+	CREATE $new CONTENT $data;
+} ELSE {
+	-- Take the legacy summary and embedding with all of their metadata, and
+	-- only where the new record has none, so hashes and timestamps always
+	-- describe the summary and vector they are stored with.
+	UPDATE $new SET
+		ai_summary = $data.ai_summary,
+		ai_categories = $data.ai_categories,
+		enriched_at = $data.enriched_at,
+		enrich_run = $data.enrich_run,
+		enrich_hash = $data.enrich_hash,
+		enrich_model = $data.enrich_model,
+		enrich_prompt = $data.enrich_prompt
+	WHERE ai_summary IS NONE;
+	UPDATE $new SET
+		embedding = $data.embedding,
+		embedded_at = $data.embedded_at,
+		embed_run = $data.embed_run
+	WHERE embedding IS NONE;
+};
+UPDATE $new SET
+	node_id = $node_id,
+	database_id = $database_id,
+	aliases = array::union(aliases ?? [], array::union($data.aliases ?? [], [$data.full_name]));
+
+FOR $list IN $lists {
+	IF array::len(SELECT id FROM in_list WHERE in = $new AND out = $list) = 0 {
+		RELATE $new->in_list->$list;
+	};
+};
+UPDATE repo_snapshot SET repo = $new WHERE repo = $old;
+-- Copy each release to its new key before deleting the old rows.
+FOR $rel IN $releases {
+	LET $id = type::thing("release", [$new_key, $rel.tag]);
+	UPSERT $id MERGE $rel;
+	UPDATE $id SET repo = $new;
+};
+DELETE release WHERE repo = $old;
+COMMIT TRANSACTION;`,
 		map[string]any{
-			"full_name": fullName,
-			"embedding": embedding,
+			"old_key":     oldKey,
+			"new_key":     newKey,
+			"node_id":     target.NodeID,
+			"database_id": target.DatabaseID,
 		})
 	if err != nil {
-		return fmt.Errorf("updating embedding for %s: %w", fullName, err)
+		return fmt.Errorf("re-keying %s to %s: %w", oldKey, newKey, err)
 	}
 	return nil
 }
@@ -254,15 +1092,29 @@ func (c *Client) VectorSearch(ctx context.Context, queryVec []float32, opts Sear
 		orderParts = append(orderParts, fmt.Sprintf("%s %s", s.Field, dir))
 	}
 
+	where := "embedding IS NOT NONE"
+	vars := map[string]any{"query_vec": queryVec}
+	if !opts.IncludeRemoved {
+		where += " AND removed_at IS NONE"
+	}
+	if opts.List != "" {
+		where += " AND " + listFilter
+		vars["list"] = opts.List
+	}
+	if opts.Source != "" {
+		where += " AND import_source CONTAINS $source"
+		vars["source"] = opts.Source
+	}
+
 	query := fmt.Sprintf(
-		"SELECT %s FROM repo WHERE embedding IS NOT NONE ORDER BY %s LIMIT %d",
+		"SELECT %s FROM repo WHERE %s ORDER BY %s LIMIT %d",
 		strings.Join(selectParts, ", "),
+		where,
 		strings.Join(orderParts, ", "),
 		opts.K,
 	)
 
-	results, err := sdk.Query[[]map[string]any](ctx, c.db, query,
-		map[string]any{"query_vec": queryVec})
+	results, err := sdk.Query[[]map[string]any](ctx, c.db, query, vars)
 	if err != nil {
 		return nil, fmt.Errorf("vector search: %w", err)
 	}
@@ -276,28 +1128,38 @@ type Stats struct {
 	Total    int
 	Enriched int
 	Embedded int
+	// Removed counts tombstoned repos, which are not included in the other
+	// counts. It is never scoped to a list: a repo is tombstoned once it has
+	// left every list.
+	Removed int
 }
 
-func (c *Client) GetStats(ctx context.Context) (*Stats, error) {
+// GetStats returns repo counts. If list is non-empty, only repos in that
+// star list (ID or name) are counted, except for Removed.
+func (c *Client) GetStats(ctx context.Context, list string) (*Stats, error) {
+	where, vars := listWhere(list)
 	results, err := sdk.Query[[]map[string]any](ctx, c.db,
 		`SELECT
-			count() AS total,
-			math::sum(IF ai_summary IS NOT NONE THEN 1 ELSE 0 END) AS enriched,
-			math::sum(IF embedding IS NOT NONE THEN 1 ELSE 0 END) AS embedded
-		FROM repo GROUP ALL`,
-		nil)
+			math::sum(IF removed_at IS NONE THEN 1 ELSE 0 END) AS total,
+			math::sum(IF removed_at IS NONE AND ai_summary IS NOT NONE THEN 1 ELSE 0 END) AS enriched,
+			math::sum(IF removed_at IS NONE AND embedding IS NOT NONE THEN 1 ELSE 0 END) AS embedded
+		FROM repo `+where+` GROUP ALL;
+		SELECT count() AS removed FROM repo WHERE removed_at IS NOT NONE GROUP ALL;`,
+		vars)
 	if err != nil {
 		return nil, fmt.Errorf("getting stats: %w", err)
 	}
-	if len(*results) == 0 || len((*results)[0].Result) == 0 {
-		return &Stats{}, nil
+	stats := &Stats{}
+	if len(*results) > 0 && len((*results)[0].Result) > 0 {
+		row := (*results)[0].Result[0]
+		stats.Total = toInt(row["total"])
+		stats.Enriched = toInt(row["enriched"])
+		stats.Embedded = toInt(row["embedded"])
+	}
+	if len(*results) > 1 && len((*results)[1].Result) > 0 {
+		stats.Removed = toInt((*results)[1].Result[0]["removed"])
 	}
-	row := (*results)[0].Result[0]
-	return &Stats{
-		Total:    toInt(row["total"]),
-		Enriched: toInt(row["enriched"]),
-		Embedded: toInt(row["embedded"]),
-	}, nil
+	return stats, nil
 }
 
 type CategoryCount struct {
@@ -305,10 +1167,17 @@ type CategoryCount struct {
 	Count    int
 }
 
-func (c *Client) GetCategoryBreakdown(ctx context.Context) ([]CategoryCount, error) {
+// GetCategoryBreakdown counts repos per AI category, optionally restricted
+// to a single star list (ID or name).
+func (c *Client) GetCategoryBreakdown(ctx context.Context, list string) ([]CategoryCount, error) {
 	// Fetch all repos with categories and compute in Go
-	results, err := sdk.Query[[]models.Repo](ctx, c.db,
-		`SELECT ai_categories FROM repo WHERE ai_categories IS NOT NONE`, nil)
+	query := `SELECT ai_categories FROM repo WHERE ai_categories IS NOT NONE AND removed_at IS NONE`
+	var vars map[string]any
+	if list != "" {
+		query += " AND " + listFilter
+		vars = map[string]any{"list": list}
+	}
+	results, err := sdk.Query[[]models.Repo](ctx, c.db, query, vars)
 	if err != nil {
 		return nil, fmt.Errorf("getting categories: %w", err)
 	}
@@ -328,6 +1197,15 @@ func (c *Client) GetCategoryBreakdown(ctx context.Context) ([]CategoryCount, err
 	return out, nil
 }
 
+// listWhere returns a WHERE clause (possibly empty) and its vars for an
+// optional star list filter.
+func listWhere(list string) (string, map[string]any) {
+	if list == "" {
+		return "", nil
+	}
+	return "WHERE " + listFilter, map[string]any{"list": list}
+}
+
 func toInt(v any) int {
 	switch n := v.(type) {
 	case float64:
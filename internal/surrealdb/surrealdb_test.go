@@ -0,0 +1,198 @@
+package surrealdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/config"
+	"github.com/kevinmichaelchen/star-watch/internal/models"
+	sdk "github.com/surrealdb/surrealdb.go"
+)
+
+// testClient connects to the SurrealDB at SURREAL_TEST_URL, using a fresh
+// database per test. Tests that need it are skipped when it isn't set.
+func testClient(t *testing.T) *Client {
+	t.Helper()
+	url := os.Getenv("SURREAL_TEST_URL")
+	if url == "" {
+		t.Skip("SURREAL_TEST_URL not set")
+	}
+	cfg := &config.Config{
+		SurrealURL:  url,
+		SurrealNS:   "star_watch_test",
+		SurrealDB:   fmt.Sprintf("t%d", time.Now().UnixNano()),
+		SurrealUser: envOr("SURREAL_TEST_USER", "root"),
+		SurrealPass: envOr("SURREAL_TEST_PASS", "root"),
+	}
+	ctx := context.Background()
+	c, err := NewClient(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = sdk.Query[any](ctx, c.db, "REMOVE DATABASE "+cfg.SurrealDB, nil)
+		_ = c.Close(ctx)
+	})
+	if err := c.InitSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// countRows returns how many records table holds.
+func countRows(t *testing.T, c *Client, table string) int {
+	t.Helper()
+	results, err := sdk.Query[[]struct {
+		Count int `json:"count"`
+	}](context.Background(), c.db, "SELECT count() FROM type::table($table) GROUP ALL", map[string]any{"table": table})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*results) == 0 || len((*results)[0].Result) == 0 {
+		return 0
+	}
+	return (*results)[0].Result[0].Count
+}
+
+func testRepo(nodeID, fullName string, stars int) models.Repo {
+	owner, name, _ := strings.Cut(fullName, "/")
+	return models.Repo{
+		NodeID:   nodeID,
+		Owner:    owner,
+		Name:     name,
+		FullName: fullName,
+		URL:      "https://github.com/" + fullName,
+		Stars:    stars,
+	}
+}
+
+// removeFromList syncs a list with keep, tombstones repos that left it and
+// prunes them.
+func removeFromList(t *testing.T, c *Client, keep []models.Repo) []string {
+	t.Helper()
+	ctx := context.Background()
+	if err := c.SyncListMembership(ctx, "UL_test", "Test", keep); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReconcileRemoved(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := c.PruneRemoved(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pruned
+}
+
+func TestPruneRemovedDeletesSnapshots(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	kept, pruned := testRepo("R_kept", "acme/kept", 10), testRepo("R_pruned", "acme/pruned", 20)
+	for _, r := range []models.Repo{kept, pruned} {
+		if err := c.UpsertRepo(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.SyncListMembership(ctx, "UL_test", "Test", []models.Repo{kept, pruned}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := removeFromList(t, c, []models.Repo{kept}); !slices.Equal(got, []string{"acme/pruned"}) {
+		t.Fatalf("PruneRemoved() = %v, want [acme/pruned]", got)
+	}
+
+	snapshots, err := c.GetSnapshots(ctx, time.Time{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].FullName != "acme/kept" {
+		t.Errorf("GetSnapshots() = %+v, want only acme/kept", snapshots)
+	}
+	if left := countRows(t, c, "repo_snapshot"); left != 1 {
+		t.Errorf("%d snapshots left after prune, want 1", left)
+	}
+}
+
+func TestPruneRemovedDeletesReleases(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	kept, pruned := testRepo("R_kept", "acme/kept", 10), testRepo("R_pruned", "acme/pruned", 20)
+	published := time.Now().Add(-time.Hour)
+	for _, r := range []models.Repo{kept, pruned} {
+		if err := c.UpsertRepo(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+		rel := models.Release{Tag: "v1.0.0", URL: r.URL + "/releases/v1.0.0", PublishedAt: &published}
+		if err := c.UpsertReleases(ctx, r, []models.Release{rel}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.SyncListMembership(ctx, "UL_test", "Test", []models.Repo{kept, pruned}); err != nil {
+		t.Fatal(err)
+	}
+
+	removeFromList(t, c, []models.Repo{kept})
+
+	releases, err := c.GetReleasesSince(ctx, time.Time{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 1 || releases[0].FullName != "acme/kept" {
+		t.Errorf("GetReleasesSince() = %+v, want only acme/kept's release", releases)
+	}
+	if left := countRows(t, c, "release"); left != 1 {
+		t.Errorf("%d releases left after prune, want 1", left)
+	}
+}
+
+func TestDropOtherLists(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	both, onlyOld := testRepo("R_both", "acme/both", 10), testRepo("R_old", "acme/old", 20)
+	for _, r := range []models.Repo{both, onlyOld} {
+		if err := c.UpsertRepo(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.SyncListMembership(ctx, "UL_keep", "Keep", []models.Repo{both}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncListMembership(ctx, "UL_old", "Old", []models.Repo{both, onlyOld}); err != nil {
+		t.Fatal(err)
+	}
+
+	dropped, err := c.DropOtherLists(ctx, []string{"UL_keep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(dropped, []string{"Old (UL_old)"}) {
+		t.Errorf("DropOtherLists() = %v, want [Old (UL_old)]", dropped)
+	}
+	if n := countRows(t, c, "in_list"); n != 1 {
+		t.Errorf("%d membership edges left, want 1", n)
+	}
+	if removed, err := c.ReconcileRemoved(ctx); err != nil || removed != 1 {
+		t.Errorf("ReconcileRemoved() = %d, %v; want 1 (acme/old)", removed, err)
+	}
+
+	// The removed count isn't scoped to the list the repo has left.
+	stats, err := c.GetStats(ctx, "Keep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 1 || stats.Removed != 1 {
+		t.Errorf("GetStats(Keep) = %+v, want 1 repo and 1 removed", stats)
+	}
+}
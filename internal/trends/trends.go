@@ -0,0 +1,126 @@
+// Package trends turns stargazer snapshots into per-repo growth figures.
+package trends
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+)
+
+// Trend summarizes one repo's star history within a window.
+type Trend struct {
+	FullName string
+	Stars    int     // latest count
+	Gained   int     // latest minus earliest count in the window
+	PerDay   float64 // Gained divided by the days between those points
+	History  []int   // star counts, oldest first
+}
+
+// Compute groups snapshots (oldest first) by repo record, so a renamed
+// repo's history stays in one series under its latest name, and returns the
+// top repos by stars gained. Repos with a single point have no measurable
+// growth and are skipped. top <= 0 returns all.
+func Compute(snapshots []surrealdb.Snapshot, top int) []Trend {
+	type series struct {
+		first, last surrealdb.Snapshot
+		history     []int
+	}
+	byRepo := map[string]*series{}
+	for _, s := range snapshots {
+		key := s.RepoKey
+		if key == "" {
+			key = s.FullName
+		}
+		ser, ok := byRepo[key]
+		if !ok {
+			ser = &series{first: s}
+			byRepo[key] = ser
+		}
+		ser.last = s
+		ser.history = append(ser.history, s.Stars)
+	}
+
+	var out []Trend
+	for _, ser := range byRepo {
+		if len(ser.history) < 2 {
+			continue
+		}
+		t := Trend{
+			FullName: ser.last.FullName,
+			Stars:    ser.last.Stars,
+			Gained:   ser.last.Stars - ser.first.Stars,
+			History:  ser.history,
+		}
+		if days := ser.last.TakenAt.Sub(ser.first.TakenAt).Hours() / 24; days > 0 {
+			t.PerDay = float64(t.Gained) / days
+		}
+		out = append(out, t)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Gained != out[j].Gained {
+			return out[i].Gained > out[j].Gained
+		}
+		return out[i].FullName < out[j].FullName
+	})
+	if top > 0 && len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a row of block characters scaled between
+// their min and max. At most width values are shown, sampled evenly.
+func Sparkline(values []int, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if width > 1 && len(values) > width {
+		sampled := make([]int, width)
+		for i := range sampled {
+			sampled[i] = values[i*(len(values)-1)/(width-1)]
+		}
+		values = sampled
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if hi > lo {
+			idx = (v - lo) * (len(sparkChars) - 1) / (hi - lo)
+		}
+		b.WriteRune(sparkChars[idx])
+	}
+	return b.String()
+}
+
+// ParseWindow parses a look-back window such as "30d", "4w" or any
+// time.ParseDuration string ("72h").
+func ParseWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("invalid window %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,34 @@
+package trends
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinmichaelchen/star-watch/internal/surrealdb"
+)
+
+func TestCompute(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 1, n, 0, 0, 0, 0, time.UTC) }
+	snapshots := []surrealdb.Snapshot{
+		{RepoKey: "R1", FullName: "acme/old", Stars: 100, TakenAt: day(1)},
+		{RepoKey: "R2", FullName: "acme/other", Stars: 50, TakenAt: day(1)},
+		{RepoKey: "R1", FullName: "acme/new", Stars: 110, TakenAt: day(2)},
+		{RepoKey: "R2", FullName: "acme/other", Stars: 52, TakenAt: day(3)},
+		{RepoKey: "R1", FullName: "acme/new", Stars: 130, TakenAt: day(3)},
+		{RepoKey: "R3", FullName: "acme/single", Stars: 5, TakenAt: day(3)},
+	}
+	got := Compute(snapshots, 0)
+	want := []Trend{
+		{FullName: "acme/new", Stars: 130, Gained: 30, PerDay: 15, History: []int{100, 110, 130}},
+		{FullName: "acme/other", Stars: 52, Gained: 2, PerDay: 1, History: []int{50, 52}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Compute() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.FullName != w.FullName || g.Stars != w.Stars || g.Gained != w.Gained || g.PerDay != w.PerDay || len(g.History) != len(w.History) {
+			t.Errorf("Compute()[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+}